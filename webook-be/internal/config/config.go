@@ -1,8 +1,42 @@
 package config
 
-type config struct {
-	DB    DBConfig
-	Redis RedisConfig
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+type Config struct {
+	Web          WebConfig
+	DB           DBConfig
+	Redis        RedisConfig
+	Wechat       WechatConfig
+	JWT          JWTConfig
+	CORS         CORSConfig
+	RateLimit    RateLimitConfig
+	Cache        CacheConfig
+	Password     PasswordConfig
+	Mail         MailConfig
+	EmailVerify  EmailVerifyConfig
+	LoginProtect LoginProtectConfig
+	MFA          MFAConfig
+	Profile      ProfileConfig
+	ObjStore     ObjStoreConfig
+	Avatar       AvatarConfig
+}
+
+type WebConfig struct {
+	// 监听地址，如 :8080
+	Addr string
+	// 不需要登录就能访问的路径
+	IgnorePaths []string
+	// 可信的反向代理，IP 或者网段，只有来自这些地址的请求才会从请求头中读取客户端 IP
+	// 为空时不信任任何代理，客户端 IP 就是连接的对端地址，无法通过 X-Forwarded-For 伪造
+	TrustedProxies []string
+	// 从哪些请求头中读取客户端 IP，为空时使用 gin 的默认值 X-Forwarded-For、X-Real-IP
+	RemoteIPHeaders []string
 }
 
 type DBConfig struct {
@@ -12,3 +46,336 @@ type DBConfig struct {
 type RedisConfig struct {
 	Addr string
 }
+
+type WechatConfig struct {
+	// Fake 使用假的微信登录，任意 code 都能登录，只能在本地开发时打开
+	Fake bool
+	// 不使用假的微信登录时必须配置
+	AppID     string
+	AppSecret string
+	// 微信登录回调地址
+	RedirectURL string
+	// 签名 state 的密钥
+	StateKey string
+}
+
+type JWTConfig struct {
+	// 签发 access token 使用的 kid
+	SigningKid string
+	// access token 的密钥，包括轮换中只用于校验的旧公钥
+	// 为空时启动时生成临时密钥，只用于本地开发
+	Keys []JWTKeyConfig
+	// 签名 refresh token 的密钥
+	RefreshSecret string
+}
+
+type JWTKeyConfig struct {
+	Kid string
+	// RS256 或者 EdDSA
+	Alg string
+	// PEM 格式，PKCS8 私钥
+	PrivateKeyFile string
+	// PEM 格式，PKIX 公钥，只用于校验
+	PublicKeyFile string
+}
+
+// CORSConfig 支持热更新
+type CORSConfig struct {
+	// 允许跨域的来源，如 http://localhost:3000
+	// 以 * 结尾时按前缀匹配，如 http://localhost*
+	AllowOrigins []string
+}
+
+// RateLimitConfig 中 IP 部分支持热更新
+type RateLimitConfig struct {
+	// 按 IP 限流
+	IP LimitConfig
+	// 发送短信的总体限流
+	SMS LimitConfig
+	// 按收件人限制重置密码、验证邮箱的邮件
+	Mail LimitConfig
+	// 按 IP 限制发送重置密码、验证邮箱的邮件
+	MailIP LimitConfig
+}
+
+type LimitConfig struct {
+	Enabled bool
+	// 窗口大小
+	Interval time.Duration
+	// 窗口内允许的请求数
+	Rate int
+}
+
+type CacheConfig struct {
+	// 用户档案缓存
+	User UserCacheConfig
+}
+
+type UserCacheConfig struct {
+	// Redis 中的过期时间
+	Expiration time.Duration
+	// 过期时间随机增加 [0, Jitter)，避免大量缓存同时过期
+	Jitter time.Duration
+	// Redis 前面的本地缓存
+	Local LocalCacheConfig
+}
+
+// LocalCacheConfig 本地缓存不会跨实例失效，过期时间要设置得短一些
+type LocalCacheConfig struct {
+	Enabled bool
+	// 最多缓存的条数
+	Size       int
+	Expiration time.Duration
+}
+
+// PasswordConfig 密码策略，注册、修改密码、重置密码共用
+type PasswordConfig struct {
+	// 最少的字符数
+	MinLength int
+	// 最多的字节数，bcrypt 只使用前 72 个字节，所以不能超过 72
+	MaxBytes int
+	// 必须包含的字符种类
+	RequireLetter bool
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// 不能包含邮箱 @ 前面的部分
+	DisallowEmail bool
+	// 不能是内置列表中的常见密码或者已泄露的密码
+	CheckCommon bool
+	// 忘记密码时通过邮件重置
+	Reset PasswordResetConfig
+}
+
+type PasswordResetConfig struct {
+	// 签名重置 token 的密钥
+	Secret string
+	// 重置链接的有效期
+	Expiration time.Duration
+	// 前端重置密码页面的地址，token 拼接在 query 中
+	URL string
+}
+
+type EmailVerifyConfig struct {
+	// 邮箱验证之前限制哪些操作
+	// none 不限制；login 不能用邮箱登录；post 可以登录，但是不能发帖、修改档案和头像
+	// 打开 login 之后，之前注册但没有验证过的账号也需要先验证才能登录
+	Require string
+	// 签名验证链接的密钥
+	Secret string
+	// 链接的有效期
+	Expiration time.Duration
+	// 前端验证邮箱页面的地址，token 拼接在 query 中
+	URL string
+}
+
+// LoginProtectConfig 邮箱密码登录的防暴力破解
+type LoginProtectConfig struct {
+	Enabled bool
+	// 统计失败次数的时间窗口
+	Window time.Duration
+	// 窗口内同一个邮箱、同一个 IP 失败这么多次后锁定
+	// 按邮箱锁定可能被人恶意锁住别人的账号，可以配合人机验证把阈值设得高一些
+	MaxEmailFailures int
+	MaxIPFailures    int
+	LockDuration     time.Duration
+	// 第 n 次失败延迟 baseDelay * 2^(n-1) 返回，最多延迟 maxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Captcha   CaptchaConfig
+}
+
+type CaptchaConfig struct {
+	Enabled bool
+	// 失败这么多次之后需要人机验证
+	Threshold int
+	// 服务商的 siteverify 接口，reCAPTCHA、hCaptcha、Turnstile 都可以
+	VerifyURL string
+	Secret    string
+}
+
+// MFAConfig TOTP 两步验证
+type MFAConfig struct {
+	// 验证器应用中显示的服务名称
+	Issuer string
+	// 加密数据库中 TOTP 密钥的 AES-256 密钥，32 个字节
+	// 修改后已经开启两步验证的用户都无法通过验证
+	EncryptKey string
+	// 第一步登录成功后，需要在这个时间内完成两步验证
+	PendingExpiration time.Duration
+	// 按用户统计验证码错误次数，窗口内错误 MaxFailures 次后锁定两步验证 LockDuration
+	FailureWindow time.Duration
+	MaxFailures   int
+	LockDuration  time.Duration
+}
+
+type ProfileConfig struct {
+	// 昵称是否不区分大小写地唯一
+	// 打开之前已经存在的重复昵称不受影响，修改昵称时才会检查
+	UniqueNickname bool
+}
+
+// ObjStoreConfig 保存头像等用户上传的文件
+type ObjStoreConfig struct {
+	// local 保存到本地目录，由 webook 提供访问；s3 兼容 S3 的对象存储，如 MinIO
+	Driver string
+	Local  LocalStoreConfig
+	S3     S3StoreConfig
+}
+
+type LocalStoreConfig struct {
+	Dir string
+	// 对外访问的地址，webook 在这个地址的路径下提供 Dir 中的文件，如 http://localhost:8080/static
+	BaseURL string
+}
+
+type S3StoreConfig struct {
+	// 如 http://localhost:9000，使用 endpoint/bucket/key 形式的地址
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// 对外访问的地址，如 CDN 的地址，为空时使用 endpoint/bucket
+	PublicURL string
+}
+
+type AvatarConfig struct {
+	// 上传的图片文件的大小上限，单位字节
+	MaxBytes int64
+	// 图片宽和高的上限
+	MaxDimension int
+	// 生成的正方形头像的边长，最大的那个保存在档案中
+	Sizes []int
+}
+
+type MailConfig struct {
+	// console 只打印邮件内容，file 把邮件写到 Dir 目录中
+	Driver string
+	Dir    string
+	// 发件人
+	From string
+}
+
+// Validate 校验必填项，启动和热更新时都会调用
+func (c *Config) Validate() error {
+	var errs []error
+	required := map[string]string{
+		"web.addr":              c.Web.Addr,
+		"db.dsn":                c.DB.DSN,
+		"redis.addr":            c.Redis.Addr,
+		"wechat.stateKey":       c.Wechat.StateKey,
+		"jwt.refreshSecret":     c.JWT.RefreshSecret,
+		"password.reset.secret": c.Password.Reset.Secret,
+		"password.reset.url":    c.Password.Reset.URL,
+		"emailVerify.secret":    c.EmailVerify.Secret,
+		"emailVerify.url":       c.EmailVerify.URL,
+		"mfa.issuer":            c.MFA.Issuer,
+	}
+	for key, val := range required {
+		if val == "" {
+			errs = append(errs, fmt.Errorf("缺少配置 %s", key))
+		}
+	}
+	if !c.Wechat.Fake && (c.Wechat.AppID == "" || c.Wechat.AppSecret == "") {
+		errs = append(errs, errors.New("缺少配置 wechat.appID 或者 wechat.appSecret，本地开发可以打开 wechat.fake"))
+	}
+	for _, p := range c.Web.TrustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			errs = append(errs, fmt.Errorf("web.trustedProxies 中的 %q 不是 IP 或者网段", p))
+		}
+	}
+	if len(c.CORS.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("缺少配置 cors.allowOrigins"))
+	}
+	if len(c.JWT.Keys) > 0 {
+		found := false
+		for i, k := range c.JWT.Keys {
+			if k.Kid == "" || k.Alg == "" {
+				errs = append(errs, fmt.Errorf("jwt.keys[%d] 缺少 kid 或者 alg", i))
+			}
+			if k.PrivateKeyFile == "" && k.PublicKeyFile == "" {
+				errs = append(errs, fmt.Errorf("jwt.keys[%d] 缺少私钥或者公钥文件", i))
+			}
+			if k.Kid == c.JWT.SigningKid {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("jwt.signingKid %q 不在 jwt.keys 中", c.JWT.SigningKid))
+		}
+	}
+	for name, l := range map[string]LimitConfig{
+		"rateLimit.ip":     c.RateLimit.IP,
+		"rateLimit.sms":    c.RateLimit.SMS,
+		"rateLimit.mail":   c.RateLimit.Mail,
+		"rateLimit.mailIP": c.RateLimit.MailIP,
+	} {
+		if l.Enabled && (l.Interval <= 0 || l.Rate <= 0) {
+			errs = append(errs, fmt.Errorf("%s 的 interval 和 rate 必须大于 0", name))
+		}
+	}
+	if c.Cache.User.Expiration <= 0 {
+		errs = append(errs, errors.New("cache.user.expiration 必须大于 0"))
+	}
+	if l := c.Cache.User.Local; l.Enabled && (l.Size <= 0 || l.Expiration <= 0) {
+		errs = append(errs, errors.New("cache.user.local 的 size 和 expiration 必须大于 0"))
+	}
+	if p := c.Password; p.MinLength <= 0 || p.MaxBytes < p.MinLength || p.MaxBytes > 72 {
+		errs = append(errs, errors.New("password.minLength 必须大于 0，且不能超过 password.maxBytes，password.maxBytes 不能超过 72"))
+	}
+	if c.Password.Reset.Expiration <= 0 {
+		errs = append(errs, errors.New("password.reset.expiration 必须大于 0"))
+	}
+	if c.EmailVerify.Expiration <= 0 {
+		errs = append(errs, errors.New("emailVerify.expiration 必须大于 0"))
+	}
+	switch c.EmailVerify.Require {
+	case "none", "login", "post":
+	default:
+		errs = append(errs, fmt.Errorf("不支持的 emailVerify.require %q", c.EmailVerify.Require))
+	}
+	if l := c.LoginProtect; l.Enabled {
+		if l.Window <= 0 || l.LockDuration <= 0 || l.MaxEmailFailures <= 0 || l.MaxIPFailures <= 0 {
+			errs = append(errs, errors.New("loginProtect 的 window、lockDuration、maxEmailFailures、maxIPFailures 必须大于 0"))
+		}
+		if l.MaxDelay < l.BaseDelay {
+			errs = append(errs, errors.New("loginProtect.maxDelay 不能小于 loginProtect.baseDelay"))
+		}
+		if c := l.Captcha; c.Enabled && (c.Threshold <= 0 || c.VerifyURL == "" || c.Secret == "") {
+			errs = append(errs, errors.New("loginProtect.captcha 需要配置 threshold、verifyURL 和 secret"))
+		}
+	}
+	if len(c.MFA.EncryptKey) != 32 {
+		errs = append(errs, errors.New("mfa.encryptKey 必须是 32 个字节，k8s 中通过 WEBOOK_MFA_ENCRYPTKEY 注入"))
+	}
+	if m := c.MFA; m.PendingExpiration <= 0 || m.FailureWindow <= 0 || m.MaxFailures <= 0 || m.LockDuration <= 0 {
+		errs = append(errs, errors.New("mfa 的 pendingExpiration、failureWindow、maxFailures、lockDuration 必须大于 0"))
+	}
+	switch c.ObjStore.Driver {
+	case "local":
+		if c.ObjStore.Local.Dir == "" || c.ObjStore.Local.BaseURL == "" {
+			errs = append(errs, errors.New("objStore.driver 为 local 时需要配置 objStore.local.dir 和 objStore.local.baseURL"))
+		}
+	case "s3":
+		if s := c.ObjStore.S3; s.Endpoint == "" || s.Region == "" || s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+			errs = append(errs, errors.New("objStore.driver 为 s3 时需要配置 objStore.s3 的 endpoint、region、bucket、accessKey 和 secretKey"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("不支持的 objStore.driver %q", c.ObjStore.Driver))
+	}
+	if a := c.Avatar; a.MaxBytes <= 0 || a.MaxDimension <= 0 || len(a.Sizes) == 0 || slices.Min(a.Sizes) <= 0 {
+		errs = append(errs, errors.New("avatar 的 maxBytes、maxDimension 必须大于 0，sizes 不能为空"))
+	}
+	switch c.Mail.Driver {
+	case "console":
+	case "file":
+		if c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail.driver 为 file 时需要配置 mail.dir"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("不支持的 mail.driver %q", c.Mail.Driver))
+	}
+	return errors.Join(errs...)
+}
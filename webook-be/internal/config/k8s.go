@@ -1,12 +0,0 @@
-//go:build k8s
-
-package config
-
-var Config = config{
-	DB: DBConfig{
-		DSN: "root:root@tcp(webook-mysql:3306)/webook",
-	},
-	Redis: RedisConfig{
-		Addr: "webook-redis:6379",
-	},
-}
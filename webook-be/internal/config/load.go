@@ -0,0 +1,172 @@
+package config
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+var (
+	current atomic.Pointer[Config]
+	v       = viper.New()
+
+	mu        sync.Mutex
+	listeners []func(cfg *Config)
+)
+
+// Load 读取配置文件，再用环境变量覆盖，校验通过后作为当前配置
+// 环境变量以 WEBOOK_ 开头，层级之间用 _ 连接，如 WEBOOK_DB_DSN
+func Load(file string) (*Config, error) {
+	v.SetConfigFile(file)
+	v.SetEnvPrefix("WEBOOK")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	setDefaults(v)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	cfg, err := unmarshal()
+	if err != nil {
+		return nil, err
+	}
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// Current 返回当前生效的配置，热更新后会返回新的配置
+func Current() *Config {
+	return current.Load()
+}
+
+// OnChange 注册热更新的回调
+func OnChange(fn func(cfg *Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Watch 监听配置文件的变化
+// 只有 CORS 和 IP 限流会热更新，其它配置需要重启才能生效
+func Watch() {
+	v.OnConfigChange(func(e fsnotify.Event) {
+		cfg, err := unmarshal()
+		if err != nil {
+			log.Println("配置文件有误，忽略本次修改,err:", err)
+			return
+		}
+		// 复制一份当前配置，只替换可以热更新的部分
+		next := *Current()
+		next.CORS = cfg.CORS
+		next.RateLimit.IP = cfg.RateLimit.IP
+		current.Store(&next)
+		log.Println("配置已更新")
+
+		mu.Lock()
+		fns := listeners
+		mu.Unlock()
+		for _, fn := range fns {
+			fn(&next)
+		}
+	})
+	v.WatchConfig()
+}
+
+func unmarshal() (*Config, error) {
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// setDefaults 设置默认值
+// viper 只会用环境变量覆盖它知道的 key，所以每个可以单独设置的配置项都要在这里出现
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("web.addr", ":8080")
+	v.SetDefault("web.ignorePaths", []string{})
+	v.SetDefault("web.trustedProxies", []string{})
+	v.SetDefault("web.remoteIPHeaders", []string{})
+	v.SetDefault("db.dsn", "")
+	v.SetDefault("redis.addr", "")
+	v.SetDefault("wechat.fake", false)
+	v.SetDefault("wechat.appID", "")
+	v.SetDefault("wechat.appSecret", "")
+	v.SetDefault("wechat.redirectURL", "")
+	v.SetDefault("wechat.stateKey", "")
+	v.SetDefault("jwt.signingKid", "")
+	v.SetDefault("jwt.refreshSecret", "")
+	v.SetDefault("cors.allowOrigins", []string{})
+	v.SetDefault("rateLimit.ip.enabled", false)
+	v.SetDefault("rateLimit.ip.interval", "1m")
+	v.SetDefault("rateLimit.ip.rate", 100)
+	v.SetDefault("rateLimit.sms.enabled", true)
+	v.SetDefault("rateLimit.sms.interval", "1s")
+	v.SetDefault("rateLimit.sms.rate", 100)
+	v.SetDefault("rateLimit.mail.enabled", true)
+	v.SetDefault("rateLimit.mail.interval", "1m")
+	v.SetDefault("rateLimit.mail.rate", 1)
+	v.SetDefault("rateLimit.mailIP.enabled", true)
+	v.SetDefault("rateLimit.mailIP.interval", "1h")
+	v.SetDefault("rateLimit.mailIP.rate", 20)
+	v.SetDefault("cache.user.expiration", "15m")
+	v.SetDefault("cache.user.jitter", "3m")
+	v.SetDefault("cache.user.local.enabled", false)
+	v.SetDefault("cache.user.local.size", 10000)
+	v.SetDefault("cache.user.local.expiration", "10s")
+	v.SetDefault("password.minLength", 8)
+	v.SetDefault("password.maxBytes", 72)
+	v.SetDefault("password.requireLetter", true)
+	v.SetDefault("password.requireUpper", false)
+	v.SetDefault("password.requireLower", false)
+	v.SetDefault("password.requireDigit", true)
+	v.SetDefault("password.requireSymbol", false)
+	v.SetDefault("password.disallowEmail", true)
+	v.SetDefault("password.checkCommon", true)
+	v.SetDefault("password.reset.secret", "")
+	v.SetDefault("password.reset.expiration", "30m")
+	v.SetDefault("password.reset.url", "")
+	v.SetDefault("emailVerify.require", "none")
+	v.SetDefault("emailVerify.secret", "")
+	v.SetDefault("emailVerify.expiration", "24h")
+	v.SetDefault("emailVerify.url", "")
+	v.SetDefault("loginProtect.enabled", true)
+	v.SetDefault("loginProtect.window", "15m")
+	v.SetDefault("loginProtect.maxEmailFailures", 5)
+	v.SetDefault("loginProtect.maxIPFailures", 20)
+	v.SetDefault("loginProtect.lockDuration", "15m")
+	v.SetDefault("loginProtect.baseDelay", "200ms")
+	v.SetDefault("loginProtect.maxDelay", "3s")
+	v.SetDefault("loginProtect.captcha.enabled", false)
+	v.SetDefault("loginProtect.captcha.threshold", 3)
+	v.SetDefault("loginProtect.captcha.verifyURL", "")
+	v.SetDefault("loginProtect.captcha.secret", "")
+	v.SetDefault("mfa.issuer", "webook")
+	v.SetDefault("mfa.encryptKey", "")
+	v.SetDefault("mfa.pendingExpiration", "5m")
+	v.SetDefault("mfa.failureWindow", "15m")
+	v.SetDefault("mfa.maxFailures", 5)
+	v.SetDefault("mfa.lockDuration", "15m")
+	v.SetDefault("profile.uniqueNickname", false)
+	v.SetDefault("objStore.driver", "local")
+	v.SetDefault("objStore.local.dir", "")
+	v.SetDefault("objStore.local.baseURL", "")
+	v.SetDefault("objStore.s3.endpoint", "")
+	v.SetDefault("objStore.s3.region", "us-east-1")
+	v.SetDefault("objStore.s3.bucket", "")
+	v.SetDefault("objStore.s3.accessKey", "")
+	v.SetDefault("objStore.s3.secretKey", "")
+	v.SetDefault("objStore.s3.publicURL", "")
+	v.SetDefault("avatar.maxBytes", 5<<20)
+	v.SetDefault("avatar.maxDimension", 4096)
+	v.SetDefault("avatar.sizes", []int{256, 128, 64})
+	v.SetDefault("mail.driver", "console")
+	v.SetDefault("mail.dir", "")
+	v.SetDefault("mail.from", "webook <noreply@webook.local>")
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("WEBOOK_DB_DSN", "root:root@tcp(mysql:3306)/webook")
+	cfg, err := Load("../../config/dev.yaml")
+	require.NoError(t, err)
+	// 环境变量覆盖配置文件
+	assert.Equal(t, "root:root@tcp(mysql:3306)/webook", cfg.DB.DSN)
+	assert.Equal(t, "localhost:6379", cfg.Redis.Addr)
+	assert.Equal(t, time.Minute, cfg.RateLimit.IP.Interval)
+	assert.Contains(t, cfg.Web.IgnorePaths, "/users/login")
+	assert.Same(t, cfg, Current())
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := Config{
+		Web:    WebConfig{Addr: ":8080", TrustedProxies: []string{"10.0.0.0/8", "proxy"}},
+		DB:     DBConfig{DSN: "dsn"},
+		Redis:  RedisConfig{Addr: "localhost:6379"},
+		Wechat: WechatConfig{StateKey: "key"},
+		JWT: JWTConfig{
+			SigningKid:    "new",
+			Keys:          []JWTKeyConfig{{Kid: "old", Alg: "EdDSA", PublicKeyFile: "old.pem"}},
+			RefreshSecret: "secret",
+		},
+		CORS:      CORSConfig{AllowOrigins: []string{"http://localhost*"}},
+		RateLimit: RateLimitConfig{IP: LimitConfig{Enabled: true}},
+		Cache:     CacheConfig{User: UserCacheConfig{Expiration: 15 * time.Minute}},
+		Password: PasswordConfig{MinLength: 8, MaxBytes: 100, Reset: PasswordResetConfig{
+			Secret: "secret", Expiration: 30 * time.Minute, URL: "http://localhost:3000/users/reset_password",
+		}},
+		Mail: MailConfig{Driver: "console"},
+		EmailVerify: EmailVerifyConfig{
+			Require: "login", Secret: "secret", Expiration: 24 * time.Hour, URL: "http://localhost:3000/users/verify_email",
+		},
+		MFA: MFAConfig{Issuer: "webook", EncryptKey: "short", PendingExpiration: 5 * time.Minute,
+			FailureWindow: 15 * time.Minute, MaxFailures: 5, LockDuration: 15 * time.Minute},
+		ObjStore: ObjStoreConfig{Driver: "local", Local: LocalStoreConfig{Dir: "./tmp", BaseURL: "http://localhost:8080/static"}},
+		Avatar:   AvatarConfig{MaxBytes: 5 << 20, MaxDimension: 4096, Sizes: []int{256, 128, 64}},
+	}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "jwt.signingKid")
+	assert.ErrorContains(t, err, "rateLimit.ip")
+	assert.ErrorContains(t, err, "password.maxBytes")
+	assert.ErrorContains(t, err, "mfa.encryptKey")
+	assert.ErrorContains(t, err, `web.trustedProxies 中的 "proxy"`)
+	// 没有打开 fake 时必须配置真实的微信应用
+	assert.ErrorContains(t, err, "wechat.appID")
+
+	cfg.JWT.SigningKid = "old"
+	cfg.Web.TrustedProxies = []string{"10.0.0.0/8", "127.0.0.1"}
+	cfg.Wechat.AppID, cfg.Wechat.AppSecret = "wx123", "secret"
+	cfg.Password.MaxBytes = 72
+	cfg.MFA.EncryptKey = "Vb3nM8qR1tY6uI0oP4aS7dF2gH5jK9lZ"
+	cfg.RateLimit.IP = LimitConfig{Enabled: true, Interval: time.Minute, Rate: 50}
+	assert.NoError(t, cfg.Validate())
+}
+
+// k8s.yaml 中不能有密钥，没有通过环境变量注入时启动失败
+func TestLoadK8s(t *testing.T) {
+	_, err := Load("../../config/k8s.yaml")
+	for _, key := range []string{"wechat.stateKey", "jwt.refreshSecret", "password.reset.secret",
+		"emailVerify.secret", "loginProtect.captcha", "mfa.encryptKey", "objStore.s3"} {
+		assert.ErrorContains(t, err, key)
+	}
+
+	for key, val := range map[string]string{
+		"WEBOOK_WECHAT_APPID":                "wx123",
+		"WEBOOK_WECHAT_APPSECRET":            "secret",
+		"WEBOOK_WECHAT_STATEKEY":             "state",
+		"WEBOOK_JWT_REFRESHSECRET":           "refresh",
+		"WEBOOK_PASSWORD_RESET_SECRET":       "reset",
+		"WEBOOK_EMAILVERIFY_SECRET":          "verify",
+		"WEBOOK_LOGINPROTECT_CAPTCHA_SECRET": "captcha",
+		"WEBOOK_MFA_ENCRYPTKEY":              "Vb3nM8qR1tY6uI0oP4aS7dF2gH5jK9lZ",
+		"WEBOOK_OBJSTORE_S3_ACCESSKEY":       "access",
+		"WEBOOK_OBJSTORE_S3_SECRETKEY":       "secret",
+	} {
+		t.Setenv(key, val)
+	}
+	cfg, err := Load("../../config/k8s.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", cfg.JWT.RefreshSecret)
+	assert.Equal(t, "access", cfg.ObjStore.S3.AccessKey)
+}
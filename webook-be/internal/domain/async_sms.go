@@ -0,0 +1,10 @@
+package domain
+
+type AsyncSms struct {
+	Id      int64
+	TplId   string
+	Args    []string
+	Numbers []string
+	// 最多重试的次数
+	RetryMax int
+}
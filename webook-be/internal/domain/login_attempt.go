@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// LoginAttempts 登录失败的统计
+type LoginAttempts struct {
+	// 统计窗口内的失败次数
+	EmailFailures int
+	IPFailures    int
+	// 剩余的锁定时间，没有锁定时为 0
+	EmailLockTTL time.Duration
+	IPLockTTL    time.Duration
+}
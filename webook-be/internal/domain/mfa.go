@@ -0,0 +1,19 @@
+package domain
+
+// MFA 用户的两步验证设置
+type MFA struct {
+	UID int64
+	// 加密后的 TOTP 密钥
+	Secret string
+	// 验证过第一个验证码之后才算开启
+	Enabled bool
+	// 最后一次使用的验证码的时间步，同一个验证码不能使用两次
+	LastStep int64
+}
+
+// MFALoginPending 第一步验证已经通过，等待两步验证的登录
+type MFALoginPending struct {
+	UID int64
+	// 第一步的登录方式，完成登录后记录到登录设备中
+	Method string
+}
@@ -0,0 +1,16 @@
+package domain
+
+// Session 一次登录
+type Session struct {
+	Ssid      string
+	Uid       int64
+	UserAgent string
+	IP        string
+	// 登录方式，如 email、sms、wechat
+	Method string
+	// 时间戳，单位秒
+	LoginTime int64
+	LastSeen  int64
+	// RefreshID 当前有效的 refresh token 的 jti，每次刷新都会换成新的
+	RefreshID string
+}
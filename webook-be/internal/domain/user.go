@@ -1,18 +1,42 @@
 package domain
 
 type User struct {
-	Id       int64
-	Email    string
-	Password []byte
-	Ctime    int64
+	Id    int64
+	Email string
+	// 邮箱是否已经验证
+	EmailVerified bool
+	Phone         string
+	Password      []byte
+	// 微信登录的用户身份
+	WechatInfo WechatInfo
+	Ctime      int64
 }
 
+// UserProfile 直接返回给前端，也用 JSON 保存在缓存中
 type UserProfile struct {
-	Id       int64
-	UID      int64
-	Email    string
-	Birthday string
-	Nickname string
-	Phone    string
-	Summary  string `json:"AboutMe"`
+	Id    int64  `json:"id"`
+	UID   int64  `json:"uid"`
+	Email string `json:"email"`
+	// 格式为 2006-01-02，没有填写时为空
+	Birthday string `json:"birthday"`
+	Nickname string `json:"nickname"`
+	Phone    string `json:"phone"`
+	Summary  string `json:"aboutMe"`
+	// 头像地址，没有上传时为空
+	AvatarURL string `json:"avatarUrl"`
+	// Version 修改档案时带上，档案已经被其它请求修改时拒绝这次修改
+	Version int64 `json:"version"`
+	// Utime 最后修改时间，Unix 秒
+	Utime int64 `json:"utime"`
+}
+
+// UserProfilePatch 部分修改档案，字段为 nil 时不修改
+type UserProfilePatch struct {
+	UID int64
+	// Version 客户端读取到的档案版本
+	Version  int64
+	Nickname *string
+	// 格式为 2006-01-02，空字符串表示清空
+	Birthday *string
+	Summary  *string
 }
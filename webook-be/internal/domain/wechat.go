@@ -0,0 +1,6 @@
+package domain
+
+type WechatInfo struct {
+	OpenID  string
+	UnionID string
+}
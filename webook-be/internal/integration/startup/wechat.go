@@ -0,0 +1,11 @@
+package startup
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/service/oauth2/wechat"
+)
+
+// InitWechatService 测试中始终使用假的微信服务
+func InitWechatService() wechat.Service {
+	return wechat.NewFakeService(config.Current().Wechat.RedirectURL)
+}
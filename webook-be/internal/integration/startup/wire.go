@@ -0,0 +1,56 @@
+//go:build wireinject
+
+package startup
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/wire"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/memory"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/ioc"
+)
+
+// InitWebServer 和 main 中的一样，但是短信和微信都换成了假的实现
+// 调用前需要先用 config.Load 加载配置
+func InitWebServer(smsSvc *memory.Service) *gin.Engine {
+	wire.Build(
+		ioc.InitDB, ioc.InitRedis, ioc.InitLogger,
+
+		dao.ProviderSet,
+		ioc.InitUserDAO,
+		cache.ProviderSet,
+		ioc.InitSessionCache,
+		ioc.InitUserCache,
+		ioc.InitLoginAttemptCache,
+		ioc.InitMFALoginCache,
+		repository.ProviderSet,
+
+		wire.Bind(new(sms.Service), new(*memory.Service)),
+		InitWechatService,
+		ioc.InitSessionService,
+		ioc.InitPasswordPolicy,
+		ioc.InitMailService,
+		ioc.InitPasswordResetService,
+		ioc.InitEmailVerifyService,
+		ioc.InitLoginGuard,
+		ioc.InitMFAService,
+		ioc.InitObjStore,
+		ioc.InitAvatarService,
+		service.ProviderSet,
+
+		ioc.InitJWTHandler,
+		middleware.ProviderSet,
+		web.ProviderSet,
+		ioc.InitOAuth2WechatHandler,
+
+		ioc.InitMiddlewares,
+		ioc.InitWebServer,
+	)
+	return new(gin.Engine)
+}
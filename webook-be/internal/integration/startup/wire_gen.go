@@ -0,0 +1,63 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package startup
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/memory"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/ioc"
+)
+
+// Injectors from wire.go:
+
+// InitWebServer 和 main 中的一样，但是短信和微信都换成了假的实现
+// 调用前需要先用 config.Load 加载配置
+func InitWebServer(smsSvc *memory.Service) *gin.Engine {
+	cmdable := ioc.InitRedis()
+	logger := ioc.InitLogger()
+	handler := ioc.InitJWTHandler()
+	sessionBlacklist := middleware.NewSessionBlacklist(cmdable)
+	sessionCache := ioc.InitSessionCache(cmdable)
+	sessionRepository := repository.NewSessionRepository(sessionCache)
+	sessionService := ioc.InitSessionService(sessionRepository)
+	v := ioc.InitMiddlewares(cmdable, logger, handler, sessionBlacklist, sessionService)
+	db := ioc.InitDB()
+	userDAO := ioc.InitUserDAO(db)
+	userCache := ioc.InitUserCache(cmdable)
+	userRepository := repository.NewUserRepository(userDAO, userCache)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	userService := service.NewUserService(userRepository, passwordPolicy)
+	codeCache := cache.NewCodeCache(cmdable)
+	codeRepository := repository.NewCodeRepository(codeCache)
+	codeService := service.NewCodeService(codeRepository, smsSvc)
+	passwordResetDAO := dao.NewPasswordResetDAO(db)
+	passwordResetRepository := repository.NewPasswordResetRepository(passwordResetDAO)
+	mailService := ioc.InitMailService()
+	passwordResetService := ioc.InitPasswordResetService(userRepository, passwordResetRepository, mailService, passwordPolicy, cmdable)
+	emailVerifyService := ioc.InitEmailVerifyService(userRepository, mailService, cmdable)
+	loginAttemptCache := ioc.InitLoginAttemptCache(cmdable)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(loginAttemptCache)
+	loginGuard := ioc.InitLoginGuard(loginAttemptRepository)
+	mfadao := dao.NewMFADAO(db)
+	mfaLoginCache := ioc.InitMFALoginCache(cmdable)
+	mfaRepository := repository.NewMFARepository(mfadao, mfaLoginCache)
+	mfaService := ioc.InitMFAService(mfaRepository, userRepository)
+	store := ioc.InitObjStore()
+	avatarService := ioc.InitAvatarService(userRepository, store)
+	userHandler := web.NewUserHandler(userService, codeService, passwordResetService, emailVerifyService, loginGuard, mfaService, avatarService, handler, sessionService, sessionBlacklist)
+	wechatService := InitWechatService()
+	oAuth2WechatHandler := ioc.InitOAuth2WechatHandler(wechatService, userService, mfaService, handler, sessionService, sessionBlacklist)
+	jwksHandler := web.NewJWKSHandler(handler)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, jwksHandler)
+	return engine
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/integration/startup"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/memory"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userAgent = "webook-integration-test"
+
+// newServer 使用 docker-compose 中的 MySQL 和 Redis 启动完整的服务，短信和微信是假的
+// MySQL 或者 Redis 连不上时跳过测试
+func newServer(t *testing.T) (*gin.Engine, *memory.Service) {
+	cfg, err := config.Load("../../config/dev.yaml")
+	require.NoError(t, err)
+	dsn, err := mysql.ParseDSN(cfg.DB.DSN)
+	require.NoError(t, err)
+	for _, addr := range []string{dsn.Addr, cfg.Redis.Addr} {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err != nil {
+			t.Skipf("连不上 %s，先用 docker-compose 启动依赖: %v", addr, err)
+		}
+		_ = conn.Close()
+	}
+	gin.SetMode(gin.TestMode)
+	smsSvc := memory.NewService()
+	return startup.InitWebServer(smsSvc), smsSvc
+}
+
+func doJSON(t *testing.T, server *gin.Engine, method, path, token string, body any) (*httptest.ResponseRecorder, ginx.Result) {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	var res ginx.Result
+	if resp.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &res))
+	}
+	return resp, res
+}
+
+func TestUserSMSLogin(t *testing.T) {
+	server, smsSvc := newServer(t)
+	// 每次使用新的手机号，第一次登录时自动注册
+	phone := fmt.Sprintf("152%08d", time.Now().UnixNano()%1e8)
+
+	_, res := doJSON(t, server, http.MethodPost, "/users/login_sms/code/send", "", map[string]string{"phone": phone})
+	require.Equal(t, 0, int(res.Code), res.Msg)
+	msg, ok := smsSvc.Last(phone)
+	require.True(t, ok)
+	require.Len(t, msg.Args, 1)
+
+	resp, res := doJSON(t, server, http.MethodPost, "/users/login_sms", "",
+		map[string]string{"phone": phone, "code": msg.Args[0]})
+	require.Equal(t, 0, int(res.Code), res.Msg)
+	token := resp.Header().Get("X-JWT-Token")
+	require.NotEmpty(t, token)
+	assert.NotEmpty(t, resp.Header().Get("X-Refresh-Token"))
+
+	_, res = doJSON(t, server, http.MethodGet, "/users/profile", token, nil)
+	require.Equal(t, 0, int(res.Code), res.Msg)
+	profile := res.Data.(map[string]any)
+	assert.Equal(t, phone, profile["phone"])
+
+	// 验证码只能用一次
+	_, res = doJSON(t, server, http.MethodPost, "/users/login_sms", "",
+		map[string]string{"phone": phone, "code": msg.Args[0]})
+	assert.NotEqual(t, 0, int(res.Code))
+
+	// 退出后 token 失效
+	_, res = doJSON(t, server, http.MethodPost, "/users/logout", token, nil)
+	require.Equal(t, 0, int(res.Code), res.Msg)
+	resp, _ = doJSON(t, server, http.MethodGet, "/users/profile", token, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+)
+
+var (
+	ErrWaitingSMSNotFound = dao.ErrWaitingSMSNotFound
+	ErrAsyncSmsPreempted  = dao.ErrAsyncSmsPreempted
+)
+
+type AsyncSmsRepository struct {
+	dao *dao.AsyncSmsDAO
+}
+
+func NewAsyncSmsRepository(dao *dao.AsyncSmsDAO) *AsyncSmsRepository {
+	return &AsyncSmsRepository{
+		dao: dao,
+	}
+}
+
+// smsConfig 短信内容在数据库中的存储格式
+type smsConfig struct {
+	TplId   string   `json:"tplId"`
+	Args    []string `json:"args"`
+	Numbers []string `json:"numbers"`
+}
+
+func (r *AsyncSmsRepository) Add(ctx context.Context, s domain.AsyncSms) error {
+	cfg, err := json.Marshal(smsConfig{
+		TplId:   s.TplId,
+		Args:    s.Args,
+		Numbers: s.Numbers,
+	})
+	if err != nil {
+		return err
+	}
+	return r.dao.Insert(ctx, dao.AsyncSms{
+		Config:   string(cfg),
+		RetryMax: s.RetryMax,
+	})
+}
+
+func (r *AsyncSmsRepository) PreemptWaitingSMS(ctx context.Context) (domain.AsyncSms, error) {
+	s, err := r.dao.GetWaitingSMS(ctx)
+	if err != nil {
+		return domain.AsyncSms{}, err
+	}
+	var cfg smsConfig
+	if err = json.Unmarshal([]byte(s.Config), &cfg); err != nil {
+		// 数据被破坏了，重试也没用，直接标记失败
+		return domain.AsyncSms{}, errors.Join(err, r.dao.MarkPermanentFailed(ctx, s.Id))
+	}
+	return domain.AsyncSms{
+		Id:       s.Id,
+		TplId:    cfg.TplId,
+		Args:     cfg.Args,
+		Numbers:  cfg.Numbers,
+		RetryMax: s.RetryMax,
+	}, nil
+}
+
+func (r *AsyncSmsRepository) ReportScheduleResult(ctx context.Context, id int64, success bool) error {
+	if success {
+		return r.dao.MarkSuccess(ctx, id)
+	}
+	return r.dao.MarkFailed(ctx, id)
+}
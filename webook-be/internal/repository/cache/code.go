@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrCodeSendTooMany   = errors.New("验证码发送太频繁")
+	ErrCodeVerifyTooMany = errors.New("验证次数太多")
+	ErrUnknownForCode    = errors.New("验证码存储出现未知错误")
+)
+
+//go:embed lua/set_code.lua
+var luaSetCode string
+
+//go:embed lua/verify_code.lua
+var luaVerifyCode string
+
+const (
+	// 验证码有效期
+	codeExpiration = 10 * time.Minute
+	// 重发间隔
+	codeResendInterval = time.Minute
+	// 每个验证码最多允许验证的次数
+	codeMaxVerifyCnt = 3
+)
+
+type CodeCache struct {
+	cmd redis.Cmdable
+}
+
+func NewCodeCache(cmd redis.Cmdable) *CodeCache {
+	return &CodeCache{
+		cmd: cmd,
+	}
+}
+
+func (c *CodeCache) Set(ctx context.Context, biz, phone, code string) error {
+	res, err := c.cmd.Eval(ctx, luaSetCode, []string{c.key(biz, phone)}, code,
+		int(codeExpiration.Seconds()), int(codeResendInterval.Seconds()), codeMaxVerifyCnt).Int()
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case -1:
+		return ErrCodeSendTooMany
+	default:
+		// key 存在但是没有过期时间
+		return ErrUnknownForCode
+	}
+}
+
+func (c *CodeCache) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	res, err := c.cmd.Eval(ctx, luaVerifyCode, []string{c.key(biz, phone)}, inputCode).Int()
+	if err != nil {
+		return false, err
+	}
+	switch res {
+	case 0:
+		return true, nil
+	case -1:
+		return false, ErrCodeVerifyTooMany
+	default:
+		// 验证码错误或已过期
+		return false, nil
+	}
+}
+
+func (c *CodeCache) key(biz, phone string) string {
+	return fmt.Sprintf("phone_code:%s:%s", biz, phone)
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 用 miniredis 执行 lua 脚本，检查脚本中对 key 的修改
+func newMiniRedis(t *testing.T) (*miniredis.Miniredis, redis.Cmdable) {
+	mr := miniredis.RunT(t)
+	return mr, redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestCodeCache_Verify(t *testing.T) {
+	mr, cmd := newMiniRedis(t)
+	c := NewCodeCache(cmd)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "login", "15212345678", "123456"))
+
+	ok, err := c.Verify(ctx, "login", "15212345678", "654321")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = c.Verify(ctx, "login", "15212345678", "123456")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 验证成功后 cnt 清零，但是仍然会和验证码一起过期
+	cntKey := "phone_code:login:15212345678:cnt"
+	val, err := mr.Get(cntKey)
+	require.NoError(t, err)
+	assert.Equal(t, "0", val)
+	assert.Equal(t, codeExpiration, mr.TTL(cntKey))
+
+	// 同一个验证码不能再用
+	_, err = c.Verify(ctx, "login", "15212345678", "123456")
+	assert.ErrorIs(t, err, ErrCodeVerifyTooMany)
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/record_login_failure.lua
+var luaRecordLoginFailure string
+
+//go:generate go run go.uber.org/mock/mockgen -source=login_attempt.go -package=cachemocks -destination=mocks/login_attempt.mock.go LoginAttemptCache
+type LoginAttemptCache interface {
+	Get(ctx context.Context, email, ip string) (domain.LoginAttempts, error)
+	// RecordFailure 记录一次失败，返回记录后的失败次数
+	// 达到上限时锁定邮箱或者 IP，同时清空对应的失败次数
+	RecordFailure(ctx context.Context, email, ip string) (domain.LoginAttempts, error)
+	// Reset 登录成功后清空邮箱的失败次数，IP 的不清空
+	Reset(ctx context.Context, email string) error
+}
+
+type RedisLoginAttemptCache struct {
+	cmd redis.Cmdable
+	// 统计失败次数的时间窗口
+	window time.Duration
+	// 窗口内失败这么多次就锁定
+	maxEmailFailures int
+	maxIPFailures    int
+	lockDuration     time.Duration
+}
+
+func NewLoginAttemptCache(cmd redis.Cmdable, window time.Duration,
+	maxEmailFailures, maxIPFailures int, lockDuration time.Duration) LoginAttemptCache {
+	return &RedisLoginAttemptCache{
+		cmd:              cmd,
+		window:           window,
+		maxEmailFailures: maxEmailFailures,
+		maxIPFailures:    maxIPFailures,
+		lockDuration:     lockDuration,
+	}
+}
+
+func (c *RedisLoginAttemptCache) Get(ctx context.Context, email, ip string) (domain.LoginAttempts, error) {
+	pipe := c.cmd.Pipeline()
+	emailCnt := pipe.Get(ctx, c.failKey("email", email))
+	ipCnt := pipe.Get(ctx, c.failKey("ip", ip))
+	emailTTL := pipe.PTTL(ctx, c.lockKey("email", email))
+	ipTTL := pipe.PTTL(ctx, c.lockKey("ip", ip))
+	// 没有失败记录时 Get 返回 redis.Nil
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return domain.LoginAttempts{}, err
+	}
+	var res domain.LoginAttempts
+	res.EmailFailures, _ = emailCnt.Int()
+	res.IPFailures, _ = ipCnt.Int()
+	// key 不存在时 PTTL 返回负数
+	res.EmailLockTTL = max(emailTTL.Val(), 0)
+	res.IPLockTTL = max(ipTTL.Val(), 0)
+	return res, nil
+}
+
+func (c *RedisLoginAttemptCache) RecordFailure(ctx context.Context, email, ip string) (domain.LoginAttempts, error) {
+	res, err := c.cmd.Eval(ctx, luaRecordLoginFailure, []string{
+		c.failKey("email", email), c.failKey("ip", ip),
+		c.lockKey("email", email), c.lockKey("ip", ip),
+	}, c.window.Milliseconds(), c.maxEmailFailures, c.maxIPFailures, c.lockDuration.Milliseconds()).Int64Slice()
+	if err != nil {
+		return domain.LoginAttempts{}, err
+	}
+	attempts := domain.LoginAttempts{EmailFailures: int(res[0]), IPFailures: int(res[1])}
+	if attempts.EmailFailures >= c.maxEmailFailures {
+		attempts.EmailLockTTL = c.lockDuration
+	}
+	if attempts.IPFailures >= c.maxIPFailures {
+		attempts.IPLockTTL = c.lockDuration
+	}
+	return attempts, nil
+}
+
+func (c *RedisLoginAttemptCache) Reset(ctx context.Context, email string) error {
+	return c.cmd.Del(ctx, c.failKey("email", email)).Err()
+}
+
+// 邮箱不区分大小写，避免换个大小写就能绕过限制
+func (c *RedisLoginAttemptCache) failKey(typ, val string) string {
+	return "login_fail:" + typ + ":" + strings.ToLower(val)
+}
+
+func (c *RedisLoginAttemptCache) lockKey(typ, val string) string {
+	return "login_lock:" + typ + ":" + strings.ToLower(val)
+}
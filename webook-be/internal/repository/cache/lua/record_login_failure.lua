@@ -0,0 +1,22 @@
+-- 记录一次登录失败，失败次数达到上限时加锁并清空计数
+-- KEYS: 邮箱失败次数, IP 失败次数, 邮箱锁, IP 锁
+-- ARGV: 统计窗口(毫秒), 邮箱最多失败次数, IP 最多失败次数, 锁定时间(毫秒)
+local function incr(key, window)
+    local cnt = redis.call("INCR", key)
+    if cnt == 1 then
+        redis.call("PEXPIRE", key, window)
+    end
+    return cnt
+end
+
+local emailCnt = incr(KEYS[1], ARGV[1])
+local ipCnt = incr(KEYS[2], ARGV[1])
+if emailCnt >= tonumber(ARGV[2]) then
+    redis.call("SET", KEYS[3], 1, "PX", ARGV[4])
+    redis.call("DEL", KEYS[1])
+end
+if ipCnt >= tonumber(ARGV[3]) then
+    redis.call("SET", KEYS[4], 1, "PX", ARGV[4])
+    redis.call("DEL", KEYS[2])
+end
+return {emailCnt, ipCnt}
@@ -0,0 +1,12 @@
+-- 记录用户的一次两步验证码错误，达到上限时锁定用户的两步验证并清空计数
+-- KEYS: 错误次数, 锁
+-- ARGV: 统计窗口(毫秒), 最多错误次数, 锁定时间(毫秒)
+local cnt = redis.call("INCR", KEYS[1])
+if cnt == 1 then
+    redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+if cnt >= tonumber(ARGV[2]) then
+    redis.call("SET", KEYS[2], 1, "PX", ARGV[3])
+    redis.call("DEL", KEYS[1])
+end
+return cnt
@@ -0,0 +1,13 @@
+-- 登录当前有效的 refresh token 的 jti
+local key = KEYS[1]
+-- 请求中的 refresh token 的 jti
+local oldID = ARGV[1]
+local newID = ARGV[2]
+local expiration = tonumber(ARGV[3])
+
+if redis.call("get", key) ~= oldID then
+    -- 不是最新的 refresh token，已经被用过或者登录已失效
+    return 0
+end
+redis.call("set", key, newID, "EX", expiration)
+return 1
@@ -0,0 +1,28 @@
+-- 验证码的 key，形如 phone_code:login:152xxxxxxxx
+local key = KEYS[1]
+-- 验证次数的 key
+local cntKey = key..":cnt"
+-- 验证码
+local val = ARGV[1]
+-- 有效期，单位秒
+local expiration = tonumber(ARGV[2])
+-- 重发间隔，单位秒
+local interval = tonumber(ARGV[3])
+-- 最多允许验证的次数
+local maxCnt = tonumber(ARGV[4])
+
+local ttl = tonumber(redis.call("ttl", key))
+if ttl == -1 then
+    -- key 存在，但是没有过期时间，说明被人为改动了
+    return -2
+elseif ttl == -2 or ttl < expiration - interval then
+    -- key 不存在，或者距离上一次发送已经超过了重发间隔
+    redis.call("set", key, val)
+    redis.call("expire", key, expiration)
+    redis.call("set", cntKey, maxCnt)
+    redis.call("expire", cntKey, expiration)
+    return 0
+else
+    -- 发送太频繁
+    return -1
+end
@@ -0,0 +1,21 @@
+-- 登录信息和最后活跃时间
+local key = KEYS[1]
+local seenKey = KEYS[2]
+local ssid = ARGV[1]
+local lastSeen = tonumber(ARGV[2])
+-- 距离上次更新不到这么多秒时不更新
+local minInterval = tonumber(ARGV[3])
+local expiration = tonumber(ARGV[4])
+
+if redis.call("hexists", key, ssid) == 0 then
+    -- 登录已经退出或者过期被清理了，不能再写回去
+    return 0
+end
+local prev = tonumber(redis.call("hget", seenKey, ssid))
+if prev ~= nil and lastSeen - prev < minInterval then
+    return 0
+end
+redis.call("hset", seenKey, ssid, lastSeen)
+redis.call("expire", seenKey, expiration)
+redis.call("expire", key, expiration)
+return 1
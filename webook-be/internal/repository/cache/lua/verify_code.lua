@@ -0,0 +1,25 @@
+local key = KEYS[1]
+local cntKey = key..":cnt"
+-- 用户输入的验证码
+local expectedCode = ARGV[1]
+
+local cnt = tonumber(redis.call("get", cntKey))
+if cnt == nil then
+    -- 验证码不存在或者已过期，当作验证码错误处理
+    return -2
+elseif cnt <= 0 then
+    -- 已经用完了验证次数
+    return -1
+end
+
+local code = redis.call("get", key)
+if code == expectedCode then
+    -- 验证成功后立即作废，避免重复使用
+    -- 保留过期时间，否则 cnt 永远不会过期
+    redis.call("set", cntKey, 0, "KEEPTTL")
+    return 0
+else
+    -- 用户输错了，可验证次数减一
+    redis.call("decr", cntKey)
+    return -2
+end
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"strconv"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMFALoginNotFound 登录不存在或者已经过期
+var ErrMFALoginNotFound = redis.Nil
+
+//go:embed lua/record_mfa_failure.lua
+var luaRecordMFAFailure string
+
+// MFALoginCache 保存第一步验证通过、等待两步验证的登录，以及每个用户两步验证的错误次数
+// 错误次数按用户统计，重新登录拿到新的 token 也不会清空
+//
+//go:generate go run go.uber.org/mock/mockgen -source=mfa.go -package=cachemocks -destination=mocks/mfa.mock.go MFALoginCache
+type MFALoginCache interface {
+	Set(ctx context.Context, token string, p domain.MFALoginPending) error
+	Get(ctx context.Context, token string) (domain.MFALoginPending, error)
+	// Delete 返回 token 是否存在，并发完成登录时只有一个会返回 true
+	Delete(ctx context.Context, token string) (bool, error)
+	// RecordFailure 记录用户的一次验证码错误，窗口内达到上限时锁定，返回锁定时间，没有锁定时返回 0
+	RecordFailure(ctx context.Context, uid int64) (time.Duration, error)
+	// LockTTL 返回用户的两步验证还要锁定多久，没有锁定时返回 0
+	LockTTL(ctx context.Context, uid int64) (time.Duration, error)
+	// ResetFailures 两步验证通过后清空错误次数
+	ResetFailures(ctx context.Context, uid int64) error
+}
+
+type RedisMFALoginCache struct {
+	cmd redis.Cmdable
+	// 两步验证需要在这个时间内完成
+	expiration time.Duration
+	// 统计错误次数的时间窗口
+	window time.Duration
+	// 窗口内错误这么多次就锁定
+	maxFailures  int
+	lockDuration time.Duration
+}
+
+func NewMFALoginCache(cmd redis.Cmdable, expiration, window time.Duration,
+	maxFailures int, lockDuration time.Duration) MFALoginCache {
+	return &RedisMFALoginCache{
+		cmd:          cmd,
+		expiration:   expiration,
+		window:       window,
+		maxFailures:  maxFailures,
+		lockDuration: lockDuration,
+	}
+}
+
+func (c *RedisMFALoginCache) Set(ctx context.Context, token string, p domain.MFALoginPending) error {
+	key := c.key(token)
+	pipe := c.cmd.TxPipeline()
+	pipe.HSet(ctx, key, "uid", p.UID, "method", p.Method)
+	pipe.Expire(ctx, key, c.expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *RedisMFALoginCache) Get(ctx context.Context, token string) (domain.MFALoginPending, error) {
+	vals, err := c.cmd.HGetAll(ctx, c.key(token)).Result()
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	// key 不存在时 HGETALL 返回空的 map
+	if len(vals) == 0 {
+		return domain.MFALoginPending{}, ErrMFALoginNotFound
+	}
+	uid, err := strconv.ParseInt(vals["uid"], 10, 64)
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	return domain.MFALoginPending{
+		UID:    uid,
+		Method: vals["method"],
+	}, nil
+}
+
+func (c *RedisMFALoginCache) Delete(ctx context.Context, token string) (bool, error) {
+	n, err := c.cmd.Del(ctx, c.key(token)).Result()
+	return n > 0, err
+}
+
+func (c *RedisMFALoginCache) RecordFailure(ctx context.Context, uid int64) (time.Duration, error) {
+	cnt, err := c.cmd.Eval(ctx, luaRecordMFAFailure, []string{c.failKey(uid), c.lockKey(uid)},
+		c.window.Milliseconds(), c.maxFailures, c.lockDuration.Milliseconds()).Int()
+	if err != nil {
+		return 0, err
+	}
+	if cnt >= c.maxFailures {
+		return c.lockDuration, nil
+	}
+	return 0, nil
+}
+
+func (c *RedisMFALoginCache) LockTTL(ctx context.Context, uid int64) (time.Duration, error) {
+	ttl, err := c.cmd.PTTL(ctx, c.lockKey(uid)).Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在时 PTTL 返回负数
+	return max(ttl, 0), nil
+}
+
+func (c *RedisMFALoginCache) ResetFailures(ctx context.Context, uid int64) error {
+	return c.cmd.Del(ctx, c.failKey(uid)).Err()
+}
+
+func (c *RedisMFALoginCache) key(token string) string {
+	return "mfa_login:" + token
+}
+
+func (c *RedisMFALoginCache) failKey(uid int64) string {
+	return "mfa_fail:" + strconv.FormatInt(uid, 10)
+}
+
+func (c *RedisMFALoginCache) lockKey(uid int64) string {
+	return "mfa_lock:" + strconv.FormatInt(uid, 10)
+}
@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: mfa.go
+//
+// Generated by this command:
+//
+//	mockgen -source=mfa.go -package=cachemocks -destination=mocks/mfa.mock.go MFALoginCache
+//
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/newton-miku/webook/webook-be/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFALoginCache is a mock of MFALoginCache interface.
+type MockMFALoginCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFALoginCacheMockRecorder
+	isgomock struct{}
+}
+
+// MockMFALoginCacheMockRecorder is the mock recorder for MockMFALoginCache.
+type MockMFALoginCacheMockRecorder struct {
+	mock *MockMFALoginCache
+}
+
+// NewMockMFALoginCache creates a new mock instance.
+func NewMockMFALoginCache(ctrl *gomock.Controller) *MockMFALoginCache {
+	mock := &MockMFALoginCache{ctrl: ctrl}
+	mock.recorder = &MockMFALoginCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFALoginCache) EXPECT() *MockMFALoginCacheMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockMFALoginCache) Delete(ctx context.Context, token string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMFALoginCacheMockRecorder) Delete(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMFALoginCache)(nil).Delete), ctx, token)
+}
+
+// Get mocks base method.
+func (m *MockMFALoginCache) Get(ctx context.Context, token string) (domain.MFALoginPending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, token)
+	ret0, _ := ret[0].(domain.MFALoginPending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockMFALoginCacheMockRecorder) Get(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMFALoginCache)(nil).Get), ctx, token)
+}
+
+// LockTTL mocks base method.
+func (m *MockMFALoginCache) LockTTL(ctx context.Context, uid int64) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockTTL", ctx, uid)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockTTL indicates an expected call of LockTTL.
+func (mr *MockMFALoginCacheMockRecorder) LockTTL(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockTTL", reflect.TypeOf((*MockMFALoginCache)(nil).LockTTL), ctx, uid)
+}
+
+// RecordFailure mocks base method.
+func (m *MockMFALoginCache) RecordFailure(ctx context.Context, uid int64) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", ctx, uid)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockMFALoginCacheMockRecorder) RecordFailure(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockMFALoginCache)(nil).RecordFailure), ctx, uid)
+}
+
+// ResetFailures mocks base method.
+func (m *MockMFALoginCache) ResetFailures(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetFailures", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetFailures indicates an expected call of ResetFailures.
+func (mr *MockMFALoginCacheMockRecorder) ResetFailures(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailures", reflect.TypeOf((*MockMFALoginCache)(nil).ResetFailures), ctx, uid)
+}
+
+// Set mocks base method.
+func (m *MockMFALoginCache) Set(ctx context.Context, token string, p domain.MFALoginPending) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, token, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockMFALoginCacheMockRecorder) Set(ctx, token, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockMFALoginCache)(nil).Set), ctx, token, p)
+}
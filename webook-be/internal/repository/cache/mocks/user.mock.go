@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user.go
+//
+// Generated by this command:
+//
+//	mockgen -source=user.go -package=cachemocks -destination=mocks/user.mock.go UserCache
+//
+
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/newton-miku/webook/webook-be/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserCache is a mock of UserCache interface.
+type MockUserCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserCacheMockRecorder
+	isgomock struct{}
+}
+
+// MockUserCacheMockRecorder is the mock recorder for MockUserCache.
+type MockUserCacheMockRecorder struct {
+	mock *MockUserCache
+}
+
+// NewMockUserCache creates a new mock instance.
+func NewMockUserCache(ctrl *gomock.Controller) *MockUserCache {
+	mock := &MockUserCache{ctrl: ctrl}
+	mock.recorder = &MockUserCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserCache) EXPECT() *MockUserCacheMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockUserCache) Delete(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserCacheMockRecorder) Delete(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserCache)(nil).Delete), ctx, uid)
+}
+
+// Get mocks base method.
+func (m *MockUserCache) Get(ctx context.Context, uid int64) (domain.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, uid)
+	ret0, _ := ret[0].(domain.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockUserCacheMockRecorder) Get(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserCache)(nil).Get), ctx, uid)
+}
+
+// Set mocks base method.
+func (m *MockUserCache) Set(ctx context.Context, p domain.UserProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockUserCacheMockRecorder) Set(ctx, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockUserCache)(nil).Set), ctx, p)
+}
+
+// SetNotFound mocks base method.
+func (m *MockUserCache) SetNotFound(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotFound", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotFound indicates an expected call of SetNotFound.
+func (mr *MockUserCacheMockRecorder) SetNotFound(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotFound", reflect.TypeOf((*MockUserCache)(nil).SetNotFound), ctx, uid)
+}
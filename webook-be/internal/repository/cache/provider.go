@@ -0,0 +1,5 @@
+package cache
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(NewCodeCache)
@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+//go:embed lua/rotate_refresh.lua
+var luaRotateRefresh string
+
+//go:embed lua/touch_session.lua
+var luaTouchSession string
+
+// SessionCache 保存每个用户当前有效的登录
+// 登录信息和最后活跃时间分两个 hash 存，更新活跃时间时不需要读出整条记录
+type SessionCache struct {
+	cmd redis.Cmdable
+	// 和 refresh token 的有效期一致
+	expiration time.Duration
+}
+
+func NewSessionCache(cmd redis.Cmdable, expiration time.Duration) *SessionCache {
+	return &SessionCache{
+		cmd:        cmd,
+		expiration: expiration,
+	}
+}
+
+// sessionEntry 登录信息在 Redis 中的存储格式
+type sessionEntry struct {
+	UserAgent string `json:"ua"`
+	IP        string `json:"ip"`
+	Method    string `json:"method"`
+	LoginTime int64  `json:"loginTime"`
+}
+
+func (c *SessionCache) Set(ctx context.Context, s domain.Session) error {
+	val, err := json.Marshal(sessionEntry{
+		UserAgent: s.UserAgent,
+		IP:        s.IP,
+		Method:    s.Method,
+		LoginTime: s.LoginTime,
+	})
+	if err != nil {
+		return err
+	}
+	key, seenKey := c.key(s.Uid), c.lastSeenKey(s.Uid)
+	pipe := c.cmd.TxPipeline()
+	pipe.HSet(ctx, key, s.Ssid, val)
+	pipe.HSet(ctx, seenKey, s.Ssid, s.LastSeen)
+	pipe.Expire(ctx, key, c.expiration)
+	pipe.Expire(ctx, seenKey, c.expiration)
+	pipe.Set(ctx, c.refreshKey(s.Ssid), s.RefreshID, c.expiration)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// RotateRefresh 当前的 refresh token 是 oldID 时换成 newID 并返回 true
+// 不是 oldID 时说明 oldID 已经被用过了，返回 false
+func (c *SessionCache) RotateRefresh(ctx context.Context, ssid, oldID, newID string) (bool, error) {
+	res, err := c.cmd.Eval(ctx, luaRotateRefresh, []string{c.refreshKey(ssid)},
+		oldID, newID, int64(c.expiration.Seconds())).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// Touch 更新最后活跃时间，距离上次更新不到 minInterval 时不更新
+// 登录已经不存在时也不更新，避免把已经清理掉的登录写回去
+func (c *SessionCache) Touch(ctx context.Context, uid int64, ssid string, lastSeen int64,
+	minInterval time.Duration) error {
+	return c.cmd.Eval(ctx, luaTouchSession, []string{c.key(uid), c.lastSeenKey(uid)},
+		ssid, lastSeen, int64(minInterval.Seconds()), int64(c.expiration.Seconds())).Err()
+}
+
+func (c *SessionCache) List(ctx context.Context, uid int64) ([]domain.Session, error) {
+	entries, err := c.cmd.HGetAll(ctx, c.key(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := c.cmd.HGetAll(ctx, c.lastSeenKey(uid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.Session, 0, len(entries))
+	for ssid, val := range entries {
+		var e sessionEntry
+		if err = json.Unmarshal([]byte(val), &e); err != nil {
+			return nil, err
+		}
+		lastSeen, _ := strconv.ParseInt(seen[ssid], 10, 64)
+		res = append(res, domain.Session{
+			Ssid:      ssid,
+			Uid:       uid,
+			UserAgent: e.UserAgent,
+			IP:        e.IP,
+			Method:    e.Method,
+			LoginTime: e.LoginTime,
+			LastSeen:  lastSeen,
+		})
+	}
+	return res, nil
+}
+
+func (c *SessionCache) Delete(ctx context.Context, uid int64, ssids ...string) error {
+	if len(ssids) == 0 {
+		return nil
+	}
+	pipe := c.cmd.TxPipeline()
+	pipe.HDel(ctx, c.key(uid), ssids...)
+	pipe.HDel(ctx, c.lastSeenKey(uid), ssids...)
+	for _, ssid := range ssids {
+		pipe.Del(ctx, c.refreshKey(ssid))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *SessionCache) key(uid int64) string {
+	return fmt.Sprintf("users:sessions:%d", uid)
+}
+
+func (c *SessionCache) lastSeenKey(uid int64) string {
+	return fmt.Sprintf("users:sessions:%d:last_seen", uid)
+}
+
+// refreshKey 保存登录当前有效的 refresh token 的 jti
+func (c *SessionCache) refreshKey(ssid string) string {
+	return fmt.Sprintf("users:sessions:refresh:%s", ssid)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrKeyNotExist 缓存中没有这个 key
+	ErrKeyNotExist = redis.Nil
+	// ErrUserNotFound 缓存中记录了这个用户不存在
+	ErrUserNotFound = errors.New("用户不存在")
+)
+
+// 不存在的用户只缓存很短的时间，新注册的用户注册时会删除这个缓存
+const notFoundExpiration = time.Minute
+
+//go:generate go run go.uber.org/mock/mockgen -source=user.go -package=cachemocks -destination=mocks/user.mock.go UserCache
+type UserCache interface {
+	// Get 缓存不存在时返回 ErrKeyNotExist，缓存了用户不存在时返回 ErrUserNotFound
+	Get(ctx context.Context, uid int64) (domain.UserProfile, error)
+	Set(ctx context.Context, p domain.UserProfile) error
+	// SetNotFound 记录用户不存在，避免不存在的 uid 每次都查询数据库
+	SetNotFound(ctx context.Context, uid int64) error
+	Delete(ctx context.Context, uid int64) error
+}
+
+// RedisUserCache 用 JSON 把用户档案保存在 Redis 中
+type RedisUserCache struct {
+	cmd        redis.Cmdable
+	expiration time.Duration
+	// 过期时间随机增加 [0, jitter)，避免同一时间写入的缓存同时过期
+	jitter time.Duration
+}
+
+func NewUserCache(cmd redis.Cmdable, expiration, jitter time.Duration) UserCache {
+	return &RedisUserCache{
+		cmd:        cmd,
+		expiration: expiration,
+		jitter:     jitter,
+	}
+}
+
+func (c *RedisUserCache) Get(ctx context.Context, uid int64) (domain.UserProfile, error) {
+	val, err := c.cmd.Get(ctx, c.key(uid)).Bytes()
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	if len(val) == 0 {
+		return domain.UserProfile{}, ErrUserNotFound
+	}
+	var p domain.UserProfile
+	err = json.Unmarshal(val, &p)
+	return p, err
+}
+
+func (c *RedisUserCache) Set(ctx context.Context, p domain.UserProfile) error {
+	val, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.cmd.Set(ctx, c.key(p.UID), val, c.ttl()).Err()
+}
+
+// SetNotFound 用空值表示用户不存在
+func (c *RedisUserCache) SetNotFound(ctx context.Context, uid int64) error {
+	return c.cmd.Set(ctx, c.key(uid), "", notFoundExpiration).Err()
+}
+
+func (c *RedisUserCache) Delete(ctx context.Context, uid int64) error {
+	return c.cmd.Del(ctx, c.key(uid)).Err()
+}
+
+func (c *RedisUserCache) ttl() time.Duration {
+	if c.jitter <= 0 {
+		return c.expiration
+	}
+	return c.expiration + rand.N(c.jitter)
+}
+
+// 档案的 JSON 格式变化时修改版本号，避免读到旧格式的缓存
+func (c *RedisUserCache) key(uid int64) string {
+	return fmt.Sprintf("user:profile:v3:%d", uid)
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+)
+
+// LocalUserCache 在另一个 UserCache 前面加一层进程内的 LRU
+// 修改只会让本实例的本地缓存失效，其它实例最多在 expiration 内读到旧数据
+type LocalUserCache struct {
+	lru  *expirable.LRU[int64, domain.UserProfile]
+	next UserCache
+}
+
+func NewLocalUserCache(next UserCache, size int, expiration time.Duration) UserCache {
+	return &LocalUserCache{
+		lru:  expirable.NewLRU[int64, domain.UserProfile](size, nil, expiration),
+		next: next,
+	}
+}
+
+func (c *LocalUserCache) Get(ctx context.Context, uid int64) (domain.UserProfile, error) {
+	if p, ok := c.lru.Get(uid); ok {
+		return p, nil
+	}
+	p, err := c.next.Get(ctx, uid)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	c.lru.Add(uid, p)
+	return p, nil
+}
+
+func (c *LocalUserCache) Set(ctx context.Context, p domain.UserProfile) error {
+	c.lru.Add(p.UID, p)
+	return c.next.Set(ctx, p)
+}
+
+// SetNotFound 本地不缓存不存在的用户，交给 Redis 处理
+func (c *LocalUserCache) SetNotFound(ctx context.Context, uid int64) error {
+	c.lru.Remove(uid)
+	return c.next.SetNotFound(ctx, uid)
+}
+
+func (c *LocalUserCache) Delete(ctx context.Context, uid int64) error {
+	c.lru.Remove(uid)
+	return c.next.Delete(ctx, uid)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+var (
+	ErrCodeSendTooMany   = errs.New(errs.CodeSendTooMany, "验证码发送太频繁，请稍后再试")
+	ErrCodeVerifyTooMany = errs.New(errs.CodeVerifyTooMany, "验证次数过多，请重新获取验证码")
+)
+
+type CodeRepository struct {
+	cache *cache.CodeCache
+}
+
+func NewCodeRepository(c *cache.CodeCache) *CodeRepository {
+	return &CodeRepository{
+		cache: c,
+	}
+}
+
+func (repo *CodeRepository) Store(ctx context.Context, biz, phone, code string) error {
+	err := repo.cache.Set(ctx, biz, phone, code)
+	if errors.Is(err, cache.ErrCodeSendTooMany) {
+		return ErrCodeSendTooMany
+	}
+	return err
+}
+
+func (repo *CodeRepository) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	ok, err := repo.cache.Verify(ctx, biz, phone, inputCode)
+	if errors.Is(err, cache.ErrCodeVerifyTooMany) {
+		return false, ErrCodeVerifyTooMany
+	}
+	return ok, err
+}
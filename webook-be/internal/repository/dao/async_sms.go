@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// 等待发送
+	AsyncStatusWaiting uint8 = iota
+	// 重试次数用完，彻底失败
+	AsyncStatusFailed
+	// 发送成功
+	AsyncStatusSuccess
+)
+
+var (
+	ErrWaitingSMSNotFound = gorm.ErrRecordNotFound
+	// 被其它实例抢先处理了
+	ErrAsyncSmsPreempted = errors.New("异步短信已被抢占")
+)
+
+type AsyncSmsDAO struct {
+	db *gorm.DB
+}
+
+func NewAsyncSmsDAO(db *gorm.DB) *AsyncSmsDAO {
+	return &AsyncSmsDAO{
+		db: db,
+	}
+}
+
+// 对应数据库表结构
+type AsyncSms struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 短信的模板、参数和手机号，JSON 格式
+	Config string
+	// 已经重试的次数
+	RetryCnt int
+	// 最多重试的次数
+	RetryMax int
+	Status   uint8 `gorm:"index:idx_status_utime"`
+	// 乐观锁版本号，抢占记录时使用
+	Version int64
+
+	Ctime int64
+	Utime int64 `gorm:"index:idx_status_utime"`
+}
+
+func (AsyncSms) TableName() string {
+	return "async_sms"
+}
+
+func (dao *AsyncSmsDAO) Insert(ctx context.Context, s AsyncSms) error {
+	now := time.Now().Unix()
+	s.Ctime = now
+	s.Utime = now
+	s.Status = AsyncStatusWaiting
+	return dao.db.WithContext(ctx).Create(&s).Error
+}
+
+// GetWaitingSMS 抢占一条等待发送的短信
+// 通过版本号做乐观锁，保证多个实例不会同时处理同一条记录
+func (dao *AsyncSmsDAO) GetWaitingSMS(ctx context.Context) (AsyncSms, error) {
+	now := time.Now().Unix()
+	// 一分钟内更新过的记录不处理，一方面是给服务商留出恢复的时间，
+	// 另一方面避免和正在发送这条短信的实例冲突
+	endTime := now - int64(time.Minute.Seconds())
+	var s AsyncSms
+	err := dao.db.WithContext(ctx).
+		Where("status = ? AND utime < ?", AsyncStatusWaiting, endTime).
+		First(&s).Error
+	if err != nil {
+		return AsyncSms{}, err
+	}
+	res := dao.db.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ? AND version = ?", s.Id, s.Version).
+		Updates(map[string]any{
+			"utime":   now,
+			"version": gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return AsyncSms{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return AsyncSms{}, ErrAsyncSmsPreempted
+	}
+	s.Version++
+	s.Utime = now
+	return s, nil
+}
+
+func (dao *AsyncSmsDAO) MarkSuccess(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"utime":  time.Now().Unix(),
+			"status": AsyncStatusSuccess,
+		}).Error
+}
+
+// MarkFailed 记录一次失败，重试次数用完后标记为彻底失败
+// MySQL 按顺序执行赋值，status 必须写在 retry_cnt 前面，判断时用的才是加一之前的值
+func (dao *AsyncSmsDAO) MarkFailed(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Exec("UPDATE async_sms SET "+
+		"status = CASE WHEN retry_cnt + 1 >= retry_max THEN ? ELSE ? END, "+
+		"retry_cnt = retry_cnt + 1, utime = ? WHERE id = ?",
+		AsyncStatusFailed, AsyncStatusWaiting, time.Now().Unix(), id).Error
+}
+
+// MarkPermanentFailed 重试也不可能成功，直接标记为彻底失败
+func (dao *AsyncSmsDAO) MarkPermanentFailed(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Model(&AsyncSms{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"utime":  time.Now().Unix(),
+			"status": AsyncStatusFailed,
+		}).Error
+}
@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB 使用 sqlmock 作为 MySQL 连接，只校验 SQL，不需要真实的数据库
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestAsyncSmsDAO_MarkFailed(t *testing.T) {
+	db, mock := newMockDB(t)
+	// status 在 retry_cnt 之前赋值，CASE 中用的是加一之前的 retry_cnt
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE async_sms SET "+
+		"status = CASE WHEN retry_cnt + 1 >= retry_max THEN ? ELSE ? END, "+
+		"retry_cnt = retry_cnt + 1, utime = ? WHERE id = ?")).
+		WithArgs(AsyncStatusFailed, AsyncStatusWaiting, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := NewAsyncSmsDAO(db).MarkFailed(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAsyncSmsDAO_MarkPermanentFailed(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `async_sms` SET `status`=?,`utime`=? WHERE id = ?")).
+		WithArgs(AsyncStatusFailed, sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := NewAsyncSmsDAO(db).MarkPermanentFailed(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
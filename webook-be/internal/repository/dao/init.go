@@ -1,8 +1,69 @@
 package dao
 
-import "gorm.io/gorm"
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
 
 // 初始化表结构
 func InitTable(db *gorm.DB) error {
-	return db.AutoMigrate(&User{}, &UserProfile{})
+	if err := migrateBirthday(db); err != nil {
+		return err
+	}
+	if err := migrateEmailVerified(db); err != nil {
+		return err
+	}
+	return db.AutoMigrate(&User{}, &UserProfile{}, &AsyncSms{}, &PasswordResetToken{},
+		&UserMFA{}, &MFARecoveryCode{})
+}
+
+// migrateBirthday 生日原来是字符串，新建档案时默认填的是注册当天
+// 改为 DATE 之前清空这些生日：
+//   - 从来没有修改过的档案
+//   - 修改过档案但是生日还是注册当天的默认值
+//   - 空字符串和其它无法转换为 DATE 的值
+//
+// 只在生日还是字符串时执行一次
+func migrateBirthday(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&UserProfile{}) {
+		return nil
+	}
+	cols, err := m.ColumnTypes(&UserProfile{})
+	if err != nil {
+		return err
+	}
+	for _, c := range cols {
+		if c.Name() == "birthday" && !strings.EqualFold(c.DatabaseTypeName(), "date") {
+			// 严格模式下 STR_TO_DATE 遇到无法转换的值会报错，IGNORE 把错误降为警告
+			return db.Exec("UPDATE IGNORE user_profiles SET birthday = NULL " +
+				"WHERE utime = ctime OR birthday = '' " +
+				"OR STR_TO_DATE(birthday, '%Y-%m-%d') IS NULL " +
+				"OR birthday = DATE(FROM_UNIXTIME(ctime))").Error
+		}
+	}
+	return nil
+}
+
+// migrateEmailVerified 加上邮箱验证之前注册的用户没有收到过验证邮件，视为已经验证过
+// 否则升级后这些用户会被限制登录或者发帖，验证时间用注册时间
+// 以 email_verified_at 列是否存在判断是否执行过，这个列最后加，中途失败时下次启动会重新执行
+func migrateEmailVerified(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&User{}) || m.HasColumn(&User{}, "EmailVerifiedAt") {
+		return nil
+	}
+	if !m.HasColumn(&User{}, "EmailVerified") {
+		if err := m.AddColumn(&User{}, "EmailVerified"); err != nil {
+			return err
+		}
+	}
+	if err := db.Exec("UPDATE users SET email_verified = true WHERE email IS NOT NULL").Error; err != nil {
+		return err
+	}
+	if err := m.AddColumn(&User{}, "EmailVerifiedAt"); err != nil {
+		return err
+	}
+	return db.Exec("UPDATE users SET email_verified_at = ctime WHERE email_verified").Error
 }
@@ -0,0 +1,156 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrMFANotFound 没有开启过两步验证
+	ErrMFANotFound       = gorm.ErrRecordNotFound
+	ErrMFAAlreadyEnabled = errors.New("已经开启了两步验证")
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=mfa.go -package=daomocks -destination=mocks/mfa.mock.go MFADAO
+type MFADAO interface {
+	FindByUID(ctx context.Context, uid int64) (UserMFA, error)
+	// SavePending 保存还没有激活的密钥，重复调用时覆盖之前的密钥
+	// 已经激活时返回 ErrMFAAlreadyEnabled
+	SavePending(ctx context.Context, uid int64, secret string) error
+	// Enable 激活两步验证，同时替换所有的恢复码
+	// step 为激活时使用的验证码的时间步
+	Enable(ctx context.Context, uid int64, step int64, codeHashes []string) error
+	// UpdateLastStep 记录最后一次使用的时间步，step 不大于已经记录的时间步时返回 false
+	UpdateLastStep(ctx context.Context, uid int64, step int64) (bool, error)
+	// UseRecoveryCode 使用一个恢复码，恢复码不存在或者已经用过时返回 false
+	UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error)
+	// Delete 关闭两步验证，同时删除所有的恢复码
+	Delete(ctx context.Context, uid int64) error
+}
+
+type GORMMFADAO struct {
+	db *gorm.DB
+}
+
+func NewMFADAO(db *gorm.DB) MFADAO {
+	return &GORMMFADAO{
+		db: db,
+	}
+}
+
+// UserMFA 用户的两步验证设置，没有开启过的用户没有记录
+type UserMFA struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	UID int64 `gorm:"uniqueIndex"`
+	// 加密后的 TOTP 密钥，base64
+	Secret string `gorm:"type:varchar(255)"`
+	// 验证过第一个验证码之后才算开启
+	Enabled bool
+	// 最后一次使用的验证码的时间步，防止同一个验证码被使用两次
+	LastStep int64
+
+	Ctime int64
+	Utime int64
+}
+
+// MFARecoveryCode 恢复码，明文只在激活时返回一次，数据库中只保存 SHA-256
+type MFARecoveryCode struct {
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	UID      int64  `gorm:"index"`
+	CodeHash string `gorm:"type:char(64)"`
+	// 使用时间，0 表示还没有使用
+	UsedAt int64
+
+	Ctime int64
+}
+
+func (dao *GORMMFADAO) FindByUID(ctx context.Context, uid int64) (UserMFA, error) {
+	var m UserMFA
+	err := dao.db.WithContext(ctx).First(&m, "uid = ?", uid).Error
+	return m, err
+}
+
+func (dao *GORMMFADAO) SavePending(ctx context.Context, uid int64, secret string) error {
+	now := time.Now().Unix()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m UserMFA
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&m, "uid = ?", uid).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(&UserMFA{
+				UID:    uid,
+				Secret: secret,
+				Ctime:  now,
+				Utime:  now,
+			}).Error
+		case err != nil:
+			return err
+		case m.Enabled:
+			return ErrMFAAlreadyEnabled
+		}
+		return tx.Model(&UserMFA{}).Where("id = ?", m.Id).Updates(map[string]any{
+			"secret":    secret,
+			"last_step": 0,
+			"utime":     now,
+		}).Error
+	})
+}
+
+func (dao *GORMMFADAO) Enable(ctx context.Context, uid int64, step int64, codeHashes []string) error {
+	now := time.Now().Unix()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 并发激活时只有一个会成功
+		res := tx.Model(&UserMFA{}).
+			Where("uid = ? AND enabled = ?", uid, false).
+			Updates(map[string]any{
+				"enabled":   true,
+				"last_step": step,
+				"utime":     now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrMFANotFound
+		}
+		if err := tx.Where("uid = ?", uid).Delete(&MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]MFARecoveryCode, 0, len(codeHashes))
+		for _, h := range codeHashes {
+			codes = append(codes, MFARecoveryCode{UID: uid, CodeHash: h, Ctime: now})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UpdateLastStep 用带条件的更新保证同一个时间步只能成功一次
+func (dao *GORMMFADAO) UpdateLastStep(ctx context.Context, uid int64, step int64) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&UserMFA{}).
+		Where("uid = ? AND last_step < ?", uid, step).
+		Updates(map[string]any{
+			"last_step": step,
+			"utime":     time.Now().Unix(),
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GORMMFADAO) UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error) {
+	res := dao.db.WithContext(ctx).Model(&MFARecoveryCode{}).
+		Where("uid = ? AND code_hash = ? AND used_at = 0", uid, codeHash).
+		Update("used_at", time.Now().Unix())
+	return res.RowsAffected > 0, res.Error
+}
+
+func (dao *GORMMFADAO) Delete(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("uid = ?", uid).Delete(&MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("uid = ?", uid).Delete(&UserMFA{}).Error
+	})
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: mfa.go
+//
+// Generated by this command:
+//
+//	mockgen -source=mfa.go -package=daomocks -destination=mocks/mfa.mock.go MFADAO
+//
+
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dao "github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFADAO is a mock of MFADAO interface.
+type MockMFADAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFADAOMockRecorder
+	isgomock struct{}
+}
+
+// MockMFADAOMockRecorder is the mock recorder for MockMFADAO.
+type MockMFADAOMockRecorder struct {
+	mock *MockMFADAO
+}
+
+// NewMockMFADAO creates a new mock instance.
+func NewMockMFADAO(ctrl *gomock.Controller) *MockMFADAO {
+	mock := &MockMFADAO{ctrl: ctrl}
+	mock.recorder = &MockMFADAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFADAO) EXPECT() *MockMFADAOMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockMFADAO) Delete(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMFADAOMockRecorder) Delete(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMFADAO)(nil).Delete), ctx, uid)
+}
+
+// Enable mocks base method.
+func (m *MockMFADAO) Enable(ctx context.Context, uid, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, uid, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockMFADAOMockRecorder) Enable(ctx, uid, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockMFADAO)(nil).Enable), ctx, uid, step, codeHashes)
+}
+
+// FindByUID mocks base method.
+func (m *MockMFADAO) FindByUID(ctx context.Context, uid int64) (dao.UserMFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUID", ctx, uid)
+	ret0, _ := ret[0].(dao.UserMFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUID indicates an expected call of FindByUID.
+func (mr *MockMFADAOMockRecorder) FindByUID(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUID", reflect.TypeOf((*MockMFADAO)(nil).FindByUID), ctx, uid)
+}
+
+// SavePending mocks base method.
+func (m *MockMFADAO) SavePending(ctx context.Context, uid int64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePending", ctx, uid, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePending indicates an expected call of SavePending.
+func (mr *MockMFADAOMockRecorder) SavePending(ctx, uid, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePending", reflect.TypeOf((*MockMFADAO)(nil).SavePending), ctx, uid, secret)
+}
+
+// UpdateLastStep mocks base method.
+func (m *MockMFADAO) UpdateLastStep(ctx context.Context, uid, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastStep", ctx, uid, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLastStep indicates an expected call of UpdateLastStep.
+func (mr *MockMFADAOMockRecorder) UpdateLastStep(ctx, uid, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastStep", reflect.TypeOf((*MockMFADAO)(nil).UpdateLastStep), ctx, uid, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFADAO) UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, uid, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFADAOMockRecorder) UseRecoveryCode(ctx, uid, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFADAO)(nil).UseRecoveryCode), ctx, uid, codeHash)
+}
@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: password_reset.go
+//
+// Generated by this command:
+//
+//	mockgen -source=password_reset.go -package=daomocks -destination=mocks/password_reset.mock.go PasswordResetDAO
+//
+
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dao "github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPasswordResetDAO is a mock of PasswordResetDAO interface.
+type MockPasswordResetDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockPasswordResetDAOMockRecorder
+	isgomock struct{}
+}
+
+// MockPasswordResetDAOMockRecorder is the mock recorder for MockPasswordResetDAO.
+type MockPasswordResetDAOMockRecorder struct {
+	mock *MockPasswordResetDAO
+}
+
+// NewMockPasswordResetDAO creates a new mock instance.
+func NewMockPasswordResetDAO(ctrl *gomock.Controller) *MockPasswordResetDAO {
+	mock := &MockPasswordResetDAO{ctrl: ctrl}
+	mock.recorder = &MockPasswordResetDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPasswordResetDAO) EXPECT() *MockPasswordResetDAOMockRecorder {
+	return m.recorder
+}
+
+// FindValid mocks base method.
+func (m *MockPasswordResetDAO) FindValid(ctx context.Context, tokenHash string, now int64) (dao.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindValid", ctx, tokenHash, now)
+	ret0, _ := ret[0].(dao.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindValid indicates an expected call of FindValid.
+func (mr *MockPasswordResetDAOMockRecorder) FindValid(ctx, tokenHash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindValid", reflect.TypeOf((*MockPasswordResetDAO)(nil).FindValid), ctx, tokenHash, now)
+}
+
+// Insert mocks base method.
+func (m *MockPasswordResetDAO) Insert(ctx context.Context, t dao.PasswordResetToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockPasswordResetDAOMockRecorder) Insert(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockPasswordResetDAO)(nil).Insert), ctx, t)
+}
+
+// Reset mocks base method.
+func (m *MockPasswordResetDAO) Reset(ctx context.Context, tokenHash, password string, now int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, tokenHash, password, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockPasswordResetDAOMockRecorder) Reset(ctx, tokenHash, password, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockPasswordResetDAO)(nil).Reset), ctx, tokenHash, password, now)
+}
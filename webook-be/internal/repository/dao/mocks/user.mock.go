@@ -0,0 +1,289 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: user.go
+//
+// Generated by this command:
+//
+//	mockgen -source=user.go -package=daomocks -destination=mocks/user.mock.go UserDAO
+//
+
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	sql "database/sql"
+	reflect "reflect"
+
+	dao "github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUserDAO is a mock of UserDAO interface.
+type MockUserDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserDAOMockRecorder
+	isgomock struct{}
+}
+
+// MockUserDAOMockRecorder is the mock recorder for MockUserDAO.
+type MockUserDAOMockRecorder struct {
+	mock *MockUserDAO
+}
+
+// NewMockUserDAO creates a new mock instance.
+func NewMockUserDAO(ctrl *gomock.Controller) *MockUserDAO {
+	mock := &MockUserDAO{ctrl: ctrl}
+	mock.recorder = &MockUserDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserDAO) EXPECT() *MockUserDAOMockRecorder {
+	return m.recorder
+}
+
+// BindPhone mocks base method.
+func (m *MockUserDAO) BindPhone(ctx context.Context, uid int64, phone string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindPhone", ctx, uid, phone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindPhone indicates an expected call of BindPhone.
+func (mr *MockUserDAOMockRecorder) BindPhone(ctx, uid, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindPhone", reflect.TypeOf((*MockUserDAO)(nil).BindPhone), ctx, uid, phone)
+}
+
+// BindWechat mocks base method.
+func (m *MockUserDAO) BindWechat(ctx context.Context, uid int64, openID, unionID sql.NullString) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BindWechat", ctx, uid, openID, unionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BindWechat indicates an expected call of BindWechat.
+func (mr *MockUserDAOMockRecorder) BindWechat(ctx, uid, openID, unionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BindWechat", reflect.TypeOf((*MockUserDAO)(nil).BindWechat), ctx, uid, openID, unionID)
+}
+
+// FindByEmail mocks base method.
+func (m *MockUserDAO) FindByEmail(ctx context.Context, email string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", ctx, email)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockUserDAOMockRecorder) FindByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserDAO)(nil).FindByEmail), ctx, email)
+}
+
+// FindByID mocks base method.
+func (m *MockUserDAO) FindByID(ctx context.Context, id int64) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockUserDAOMockRecorder) FindByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockUserDAO)(nil).FindByID), ctx, id)
+}
+
+// FindByPhone mocks base method.
+func (m *MockUserDAO) FindByPhone(ctx context.Context, phone string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByPhone", ctx, phone)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByPhone indicates an expected call of FindByPhone.
+func (mr *MockUserDAOMockRecorder) FindByPhone(ctx, phone any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByPhone", reflect.TypeOf((*MockUserDAO)(nil).FindByPhone), ctx, phone)
+}
+
+// FindByWechatOpenID mocks base method.
+func (m *MockUserDAO) FindByWechatOpenID(ctx context.Context, openID string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatOpenID", ctx, openID)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatOpenID indicates an expected call of FindByWechatOpenID.
+func (mr *MockUserDAOMockRecorder) FindByWechatOpenID(ctx, openID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatOpenID", reflect.TypeOf((*MockUserDAO)(nil).FindByWechatOpenID), ctx, openID)
+}
+
+// FindByWechatUnionID mocks base method.
+func (m *MockUserDAO) FindByWechatUnionID(ctx context.Context, unionID string) (dao.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByWechatUnionID", ctx, unionID)
+	ret0, _ := ret[0].(dao.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByWechatUnionID indicates an expected call of FindByWechatUnionID.
+func (mr *MockUserDAOMockRecorder) FindByWechatUnionID(ctx, unionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByWechatUnionID", reflect.TypeOf((*MockUserDAO)(nil).FindByWechatUnionID), ctx, unionID)
+}
+
+// FindProfileByID mocks base method.
+func (m *MockUserDAO) FindProfileByID(ctx context.Context, uid int64) (dao.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProfileByID", ctx, uid)
+	ret0, _ := ret[0].(dao.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProfileByID indicates an expected call of FindProfileByID.
+func (mr *MockUserDAOMockRecorder) FindProfileByID(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProfileByID", reflect.TypeOf((*MockUserDAO)(nil).FindProfileByID), ctx, uid)
+}
+
+// Insert mocks base method.
+func (m *MockUserDAO) Insert(ctx context.Context, u dao.User) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", ctx, u)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockUserDAOMockRecorder) Insert(ctx, u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUserDAO)(nil).Insert), ctx, u)
+}
+
+// InsertProfile mocks base method.
+func (m *MockUserDAO) InsertProfile(ctx context.Context, up dao.UserProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertProfile", ctx, up)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertProfile indicates an expected call of InsertProfile.
+func (mr *MockUserDAOMockRecorder) InsertProfile(ctx, up any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertProfile", reflect.TypeOf((*MockUserDAO)(nil).InsertProfile), ctx, up)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserDAO) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, uid, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserDAOMockRecorder) MarkEmailVerified(ctx, uid, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserDAO)(nil).MarkEmailVerified), ctx, uid, email)
+}
+
+// Merge mocks base method.
+func (m *MockUserDAO) Merge(ctx context.Context, targetID, sourceID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", ctx, targetID, sourceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockUserDAOMockRecorder) Merge(ctx, targetID, sourceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockUserDAO)(nil).Merge), ctx, targetID, sourceID)
+}
+
+// UnbindPhone mocks base method.
+func (m *MockUserDAO) UnbindPhone(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbindPhone", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbindPhone indicates an expected call of UnbindPhone.
+func (mr *MockUserDAOMockRecorder) UnbindPhone(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbindPhone", reflect.TypeOf((*MockUserDAO)(nil).UnbindPhone), ctx, uid)
+}
+
+// UnbindWechat mocks base method.
+func (m *MockUserDAO) UnbindWechat(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbindWechat", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnbindWechat indicates an expected call of UnbindWechat.
+func (mr *MockUserDAOMockRecorder) UnbindWechat(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbindWechat", reflect.TypeOf((*MockUserDAO)(nil).UnbindWechat), ctx, uid)
+}
+
+// UpdateAvatar mocks base method.
+func (m *MockUserDAO) UpdateAvatar(ctx context.Context, uid int64, avatarURL string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAvatar", ctx, uid, avatarURL)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAvatar indicates an expected call of UpdateAvatar.
+func (mr *MockUserDAOMockRecorder) UpdateAvatar(ctx, uid, avatarURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAvatar", reflect.TypeOf((*MockUserDAO)(nil).UpdateAvatar), ctx, uid, avatarURL)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserDAO) UpdatePassword(ctx context.Context, uid int64, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, uid, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserDAOMockRecorder) UpdatePassword(ctx, uid, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserDAO)(nil).UpdatePassword), ctx, uid, hash)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserDAO) UpdateProfile(ctx context.Context, up dao.UserProfileUpdate) (dao.UserProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", ctx, up)
+	ret0, _ := ret[0].(dao.UserProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserDAOMockRecorder) UpdateProfile(ctx, up any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserDAO)(nil).UpdateProfile), ctx, up)
+}
@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrResetTokenNotFound token 不存在、已经用过或者已经过期
+var ErrResetTokenNotFound = gorm.ErrRecordNotFound
+
+//go:generate go run go.uber.org/mock/mockgen -source=password_reset.go -package=daomocks -destination=mocks/password_reset.mock.go PasswordResetDAO
+type PasswordResetDAO interface {
+	Insert(ctx context.Context, t PasswordResetToken) error
+	// FindValid 查找未使用且未过期的 token
+	FindValid(ctx context.Context, tokenHash string, now int64) (PasswordResetToken, error)
+	// Reset 使用 token 并修改密码，同时作废这个用户其它未使用的 token
+	// password 为加密后的密码
+	Reset(ctx context.Context, tokenHash, password string, now int64) error
+}
+
+type GORMPasswordResetDAO struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetDAO(db *gorm.DB) PasswordResetDAO {
+	return &GORMPasswordResetDAO{
+		db: db,
+	}
+}
+
+// PasswordResetToken 重置密码的 token，明文只出现在邮件中，数据库中只保存签名
+type PasswordResetToken struct {
+	Id  int64 `gorm:"primaryKey,autoIncrement"`
+	UID int64 `gorm:"index"`
+	// token 的 HMAC-SHA256，十六进制
+	TokenHash string `gorm:"type:char(64);uniqueIndex"`
+	// 过期时间，时间戳
+	ExpireAt int64
+	// 使用时间，0 表示还没有使用
+	UsedAt int64
+
+	Ctime int64
+}
+
+func (dao *GORMPasswordResetDAO) Insert(ctx context.Context, t PasswordResetToken) error {
+	t.Ctime = time.Now().Unix()
+	return dao.db.WithContext(ctx).Create(&t).Error
+}
+
+func (dao *GORMPasswordResetDAO) FindValid(ctx context.Context, tokenHash string, now int64) (PasswordResetToken, error) {
+	var t PasswordResetToken
+	err := dao.db.WithContext(ctx).
+		First(&t, "token_hash = ? AND used_at = 0 AND expire_at > ?", tokenHash, now).Error
+	return t, err
+}
+
+// Reset 用带条件的更新保证 token 只能使用一次，并发使用时只有一个会成功
+// 使用 token 和修改密码在同一个事务中，不会出现 token 用掉了密码却没有改的情况
+func (dao *GORMPasswordResetDAO) Reset(ctx context.Context, tokenHash, password string, now int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var t PasswordResetToken
+		if err := tx.First(&t, "token_hash = ?", tokenHash).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at = 0 AND expire_at > ?", t.Id, now).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrResetTokenNotFound
+		}
+		// 发过多封重置邮件时，其它链接也一起失效
+		err := tx.Model(&PasswordResetToken{}).
+			Where("uid = ? AND used_at = 0", t.UID).
+			Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&User{}).Where("id = ?", t.UID).Updates(map[string]any{
+			"password": password,
+			"utime":    now,
+		}).Error
+	})
+}
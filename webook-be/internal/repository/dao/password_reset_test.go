@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGORMPasswordResetDAO_Reset(t *testing.T) {
+	const now = int64(1700000000)
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "使用 token 并修改密码",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `password_reset_tokens` WHERE token_hash = ?")).
+					WithArgs("hash", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "uid"}).AddRow(1, 123))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_reset_tokens` SET `used_at`=? WHERE id = ? AND used_at = 0 AND expire_at > ?")).
+					WithArgs(now, int64(1), now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_reset_tokens` SET `used_at`=? WHERE uid = ? AND used_at = 0")).
+					WithArgs(now, int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET `password`=?,`utime`=? WHERE id = ?")).
+					WithArgs("bcrypt", now, int64(123)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "token 已经用过时不修改密码",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `password_reset_tokens` WHERE token_hash = ?")).
+					WithArgs("hash", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "uid"}).AddRow(1, 123))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_reset_tokens` SET `used_at`=? WHERE id = ? AND used_at = 0 AND expire_at > ?")).
+					WithArgs(now, int64(1), now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrResetTokenNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			tc.mock(mock)
+
+			err := NewPasswordResetDAO(db).Reset(context.Background(), "hash", "bcrypt", now)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package dao
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(NewAsyncSmsDAO, NewPasswordResetDAO, NewMFADAO)
@@ -2,61 +2,137 @@ package dao
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
-	ErrUserDuplicateEmail   = errors.New("邮箱已被注册")
-	ErrUserProfileDuplicate = errors.New("档案重复")
-	ErrUserNotFound         = gorm.ErrRecordNotFound
-	ErrUserProfileNotFound  = gorm.ErrRecordNotFound
+	ErrUserDuplicateEmail    = errors.New("邮箱已被注册")
+	ErrUserDuplicatePhone    = errors.New("手机号已被注册")
+	ErrUserDuplicateWechat   = errors.New("微信已被注册")
+	ErrUserDuplicateNickname = errors.New("昵称已被使用")
+	ErrUserProfileDuplicate  = errors.New("档案重复")
+	ErrUserProfileConflict   = errors.New("档案已被修改")
+	ErrUserMergeConflict     = errors.New("两个账号的登录方式冲突，不能合并")
+	ErrUserNotFound          = gorm.ErrRecordNotFound
+	ErrUserProfileNotFound   = gorm.ErrRecordNotFound
 )
 
-type UserDAO struct {
+//go:generate go run go.uber.org/mock/mockgen -source=user.go -package=daomocks -destination=mocks/user.mock.go UserDAO
+type UserDAO interface {
+	FindByEmail(ctx context.Context, email string) (User, error)
+	FindByPhone(ctx context.Context, phone string) (User, error)
+	FindByWechatOpenID(ctx context.Context, openID string) (User, error)
+	FindByWechatUnionID(ctx context.Context, unionID string) (User, error)
+	FindByID(ctx context.Context, id int64) (User, error)
+	FindProfileByID(ctx context.Context, uid int64) (UserProfile, error)
+	Insert(ctx context.Context, u User) (int64, error)
+	InsertProfile(ctx context.Context, up UserProfile) error
+	// UpdateProfile 只修改不为 nil 的字段，返回修改后的档案
+	// 版本号不是 up.Version 时返回 ErrUserProfileConflict
+	UpdateProfile(ctx context.Context, up UserProfileUpdate) (UserProfile, error)
+	// UpdateAvatar 档案不存在时返回 ErrUserProfileNotFound
+	UpdateAvatar(ctx context.Context, uid int64, avatarURL string) error
+	BindPhone(ctx context.Context, uid int64, phone string) error
+	UnbindPhone(ctx context.Context, uid int64) error
+	BindWechat(ctx context.Context, uid int64, openID, unionID sql.NullString) error
+	UnbindWechat(ctx context.Context, uid int64) error
+	// Merge 两个账号有同一种登录方式，或者 source 开启了两步验证时返回 ErrUserMergeConflict
+	Merge(ctx context.Context, targetID, sourceID int64) error
+	UpdatePassword(ctx context.Context, uid int64, hash string) error
+	// MarkEmailVerified email 必须还是用户当前的邮箱，否则返回 ErrUserNotFound
+	MarkEmailVerified(ctx context.Context, uid int64, email string) error
+}
+
+// GORMUserDAO 基于 GORM 的 UserDAO 实现
+type GORMUserDAO struct {
 	db *gorm.DB
+	// 昵称是否不区分大小写地唯一
+	uniqueNickname bool
 }
 
-func (dao *UserDAO) FindByEmail(ctx context.Context, email string) (User, error) {
+func (dao *GORMUserDAO) FindByEmail(ctx context.Context, email string) (User, error) {
 	var u User
 	err := dao.db.WithContext(ctx).First(&u, "email = ?", email).Error
 	return u, err
 }
 
-func (dao *UserDAO) FindByID(ctx context.Context, id int64) (User, error) {
+func (dao *GORMUserDAO) FindByPhone(ctx context.Context, phone string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).First(&u, "phone = ?", phone).Error
+	return u, err
+}
+
+func (dao *GORMUserDAO) FindByWechatOpenID(ctx context.Context, openID string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).First(&u, "wechat_open_id = ?", openID).Error
+	return u, err
+}
+
+func (dao *GORMUserDAO) FindByWechatUnionID(ctx context.Context, unionID string) (User, error) {
+	var u User
+	err := dao.db.WithContext(ctx).First(&u, "wechat_union_id = ?", unionID).Error
+	return u, err
+}
+
+func (dao *GORMUserDAO) FindByID(ctx context.Context, id int64) (User, error) {
 	var u User
 	err := dao.db.WithContext(ctx).First(&u, "id = ?", id).Error
 	return u, err
 }
 
-func (dao *UserDAO) FindProfileByID(ctx context.Context, uid int64) (UserProfile, error) {
+func (dao *GORMUserDAO) FindProfileByID(ctx context.Context, uid int64) (UserProfile, error) {
 	var u UserProfile
-	err := dao.db.WithContext(ctx).First(&u, "UID = ?", uid).Error
-	if errors.Is(err, ErrUserProfileNotFound) {
-		u.UID = uid
-		err = dao.InsertProfile(ctx, u)
-		if err != nil {
-			return UserProfile{}, err
-		}
-		return dao.FindProfileByID(ctx, uid)
+	err := dao.db.WithContext(ctx).First(&u, "uid = ?", uid).Error
+	if !errors.Is(err, ErrUserProfileNotFound) {
+		return u, err
 	}
+	// 只给真实存在的用户补建档案，避免随便一个 uid 都会往表里插入数据
+	user, err := dao.FindByID(ctx, uid)
+	if err != nil {
+		return UserProfile{}, err
+	}
+	err = dao.InsertProfile(ctx, UserProfile{
+		UID:         uid,
+		Email:       user.Email.String,
+		PhoneNumber: user.Phone.String,
+	})
+	// 并发补建时唯一索引冲突，说明档案已经建好了
+	if err != nil && !errors.Is(err, ErrUserProfileDuplicate) {
+		return UserProfile{}, err
+	}
+	err = dao.db.WithContext(ctx).First(&u, "uid = ?", uid).Error
 	return u, err
 }
 
-func NewUserDAO(db *gorm.DB) *UserDAO {
-	return &UserDAO{
-		db: db,
+// NewUserDAO 开启 uniqueNickname 之前已经存在的档案，修改后才会检查昵称是否唯一
+func NewUserDAO(db *gorm.DB, uniqueNickname bool) UserDAO {
+	return &GORMUserDAO{
+		db:             db,
+		uniqueNickname: uniqueNickname,
 	}
 }
 
 // 对应数据库表结构
 type User struct {
-	Id       int64  `gorm:"primaryKey,autoIncrement"`
-	Email    string `gorm:"unique"`
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 邮箱和手机号都可能为空，用 NULL 表示，避免空字符串触发唯一索引冲突
+	Email    sql.NullString `gorm:"unique"`
+	Phone    sql.NullString `gorm:"unique"`
 	Password string
+	// 邮箱是否已经验证，以及验证的时间戳
+	EmailVerified   bool
+	EmailVerifiedAt int64
+
+	// 微信登录的用户身份
+	WechatOpenID  sql.NullString `gorm:"unique"`
+	WechatUnionID sql.NullString `gorm:"unique"`
 
 	// 时间统一为UTC+0
 	// 创建时间，时间戳
@@ -66,67 +142,287 @@ type User struct {
 }
 
 type UserProfile struct {
-	Id          int64 `gorm:"primaryKey,autoIncrement"`
-	UID         int64 `gorm:"uniqueIndex"`
-	Nickname    string
+	Id       int64  `gorm:"primaryKey,autoIncrement"`
+	UID      int64  `gorm:"uniqueIndex"`
+	Nickname string `gorm:"type:varchar(64)"`
+	// 小写的昵称，只在开启了昵称唯一时写入，用唯一索引保证昵称不区分大小写地唯一
+	// 为 NULL 时不受唯一索引限制
+	NicknameKey sql.NullString `gorm:"type:varchar(64);uniqueIndex"`
 	Email       string
 	PhoneNumber string
-	Birthday    string
-	Summary     string
+	// 没有填写时为 NULL，时区固定为 UTC
+	Birthday sql.NullTime `gorm:"type:date"`
+	Summary  string
+	// 头像地址
+	AvatarURL string `gorm:"type:varchar(512)"`
+	// 乐观锁，每次通过 UpdateProfile 修改后加一
+	// 头像、手机号等不在编辑页面修改的字段不改变版本号
+	Version int64 `gorm:"not null;default:1"`
 
 	Ctime int64
 	Utime int64
 }
 
-func (dao *UserDAO) UpdateProfile(ctx context.Context, up UserProfile) error {
-	now := time.Now().Unix()
-	u := UserProfile{}
-	err := dao.db.WithContext(ctx).First(&u, "UID = ?", up.UID).Error
-	if err != nil {
-		return err
+// UserProfileUpdate 档案的部分修改，字段为 nil 时不修改
+type UserProfileUpdate struct {
+	UID int64
+	// 修改前的版本号
+	Version  int64
+	Nickname *string
+	Birthday *sql.NullTime
+	Summary  *string
+}
+
+func (dao *GORMUserDAO) UpdateProfile(ctx context.Context, up UserProfileUpdate) (UserProfile, error) {
+	updates := map[string]any{
+		"version": gorm.Expr("version + 1"),
+		"utime":   time.Now().Unix(),
+	}
+	if up.Nickname != nil {
+		updates["nickname"] = *up.Nickname
+		updates["nickname_key"] = dao.nicknameKey(*up.Nickname)
+	}
+	if up.Birthday != nil {
+		updates["birthday"] = *up.Birthday
+	}
+	if up.Summary != nil {
+		updates["summary"] = *up.Summary
+	}
+	var u UserProfile
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&UserProfile{}).Where("uid = ? AND version = ?", up.UID, up.Version).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := tx.First(&u, "uid = ?", up.UID).Error; err != nil {
+			return err
+		}
+		// 档案存在但是没有修改，说明版本号已经变了
+		if res.RowsAffected == 0 {
+			return ErrUserProfileConflict
+		}
+		return nil
+	})
+	if isUniqueConflict(err) {
+		return UserProfile{}, ErrUserDuplicateNickname
+	}
+	return u, err
+}
+
+func (dao *GORMUserDAO) UpdateAvatar(ctx context.Context, uid int64, avatarURL string) error {
+	res := dao.db.WithContext(ctx).Model(&UserProfile{}).Where("uid = ?", uid).Updates(map[string]any{
+		"avatar_url": avatarURL,
+		"utime":      time.Now().Unix(),
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserProfileNotFound
 	}
-	u.Birthday = up.Birthday
-	u.Nickname = up.Nickname
-	u.Summary = up.Summary
-	u.Utime = now
-	dao.db.WithContext(ctx).Save(&u)
 	return nil
 }
-func (dao *UserDAO) InsertProfile(ctx context.Context, up UserProfile) error {
+
+// nicknameKey 没有开启昵称唯一或者昵称为空时返回 NULL
+func (dao *GORMUserDAO) nicknameKey(nickname string) sql.NullString {
+	key := strings.ToLower(strings.TrimSpace(nickname))
+	return sql.NullString{String: key, Valid: dao.uniqueNickname && key != ""}
+}
+
+func (dao *GORMUserDAO) InsertProfile(ctx context.Context, up UserProfile) error {
 	now := time.Now().Unix()
 	up.Ctime = now
 	up.Utime = now
-	up.Birthday = time.Now().Format("2006-01-02")
+	up.NicknameKey = dao.nicknameKey(up.Nickname)
+	up.Version = 1
 	if up.UID != 0 {
-		// 没有邮箱信息则查询数据
-		if up.Email == "" {
+		// 没有邮箱或手机号信息则查询数据
+		if up.Email == "" || up.PhoneNumber == "" {
 			user, err := dao.FindByID(ctx, up.UID)
 			if err != nil {
 				return err
 			}
-			up.Email = user.Email
+			up.Email = user.Email.String
+			up.PhoneNumber = user.Phone.String
 		}
 	}
 	err := dao.db.WithContext(ctx).Create(&up).Error
-	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-		const uniqueConflictErr uint16 = 1062
-		if mysqlErr.Number == uniqueConflictErr {
-			return ErrUserDuplicateEmail
-		}
+	if isUniqueConflict(err) {
+		// 新建的档案都没有昵称，只会是 uid 冲突
+		return ErrUserProfileDuplicate
 	}
-	return nil
+	return err
 }
 
-func (dao *UserDAO) Insert(ctx context.Context, u User) error {
+// Insert 创建用户和对应的档案，返回新用户的 id
+func (dao *GORMUserDAO) Insert(ctx context.Context, u User) (int64, error) {
 	now := time.Now().Unix()
 	u.Ctime = now
 	u.Utime = now
 	err := dao.db.WithContext(ctx).Create(&u).Error
-	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-		const uniqueConflictErr uint16 = 1062
-		if mysqlErr.Number == uniqueConflictErr {
-			return ErrUserDuplicateEmail
+	if isUniqueConflict(err) {
+		switch {
+		case u.Email.Valid:
+			return 0, ErrUserDuplicateEmail
+		case u.Phone.Valid:
+			return 0, ErrUserDuplicatePhone
+		default:
+			return 0, ErrUserDuplicateWechat
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return u.Id, dao.InsertProfile(ctx, UserProfile{UID: u.Id, Email: u.Email.String, PhoneNumber: u.Phone.String})
+}
+
+// BindPhone 给用户绑定手机号，同时同步档案中的手机号
+func (dao *GORMUserDAO) BindPhone(ctx context.Context, uid int64, phone string) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", uid).Updates(map[string]any{
+			"phone": sql.NullString{String: phone, Valid: true},
+			"utime": time.Now().Unix(),
+		}).Error
+		if isUniqueConflict(err) {
+			return ErrUserDuplicatePhone
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&UserProfile{}).Where("uid = ?", uid).
+			Update("phone_number", phone).Error
+	})
+}
+
+// UnbindPhone 解绑手机号
+func (dao *GORMUserDAO) UnbindPhone(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&User{}).Where("id = ?", uid).Updates(map[string]any{
+			"phone": sql.NullString{},
+			"utime": time.Now().Unix(),
+		}).Error
+		if err != nil {
+			return err
 		}
+		return tx.Model(&UserProfile{}).Where("uid = ?", uid).
+			Update("phone_number", "").Error
+	})
+}
+
+func (dao *GORMUserDAO) BindWechat(ctx context.Context, uid int64, openID, unionID sql.NullString) error {
+	err := dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).Updates(map[string]any{
+		"wechat_open_id":  openID,
+		"wechat_union_id": unionID,
+		"utime":           time.Now().Unix(),
+	}).Error
+	if isUniqueConflict(err) {
+		return ErrUserDuplicateWechat
+	}
+	return err
+}
+
+func (dao *GORMUserDAO) UnbindWechat(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).Updates(map[string]any{
+		"wechat_open_id":  sql.NullString{},
+		"wechat_union_id": sql.NullString{},
+		"utime":           time.Now().Unix(),
+	}).Error
+}
+
+// UpdatePassword hash 为加密后的密码
+func (dao *GORMUserDAO) UpdatePassword(ctx context.Context, uid int64, hash string) error {
+	return dao.db.WithContext(ctx).Model(&User{}).Where("id = ?", uid).Updates(map[string]any{
+		"password": hash,
+		"utime":    time.Now().Unix(),
+	}).Error
+}
+
+// MarkEmailVerified 带上邮箱作为条件，避免验证的是已经换掉的旧邮箱
+func (dao *GORMUserDAO) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	now := time.Now().Unix()
+	res := dao.db.WithContext(ctx).Model(&User{}).Where("id = ? AND email = ?", uid, email).Updates(map[string]any{
+		"email_verified":    true,
+		"email_verified_at": now,
+		"utime":             now,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotFound
 	}
-	return dao.InsertProfile(ctx, UserProfile{UID: u.Id, Email: u.Email})
+	return nil
+}
+
+// Merge 把 source 账号合并到 target 账号，合并后 source 账号以及它的两步验证、重置密码记录被删除
+// target 没有的登录方式从 source 补过来
+// 两个账号有同一种登录方式时返回 ErrUserMergeConflict，避免悄悄丢掉 source 的登录方式；
+// source 开启了两步验证时也返回 ErrUserMergeConflict，否则转移过来的登录方式就不再受两步验证保护
+func (dao *GORMUserDAO) Merge(ctx context.Context, targetID, sourceID int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target, source User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&target, "id = ?", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&source, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if (target.Email.Valid && source.Email.Valid) ||
+			(target.Phone.Valid && source.Phone.Valid) ||
+			(target.WechatOpenID.Valid && source.WechatOpenID.Valid) {
+			return ErrUserMergeConflict
+		}
+		var mfaCnt int64
+		if err := tx.Model(&UserMFA{}).Where("uid = ? AND enabled = ?", sourceID, true).
+			Count(&mfaCnt).Error; err != nil {
+			return err
+		}
+		if mfaCnt > 0 {
+			return ErrUserMergeConflict
+		}
+		if !target.Email.Valid && source.Email.Valid {
+			target.Email = source.Email
+			target.Password = source.Password
+			target.EmailVerified = source.EmailVerified
+			target.EmailVerifiedAt = source.EmailVerifiedAt
+		}
+		if !target.Phone.Valid {
+			target.Phone = source.Phone
+		}
+		if !target.WechatOpenID.Valid {
+			target.WechatOpenID = source.WechatOpenID
+			target.WechatUnionID = source.WechatUnionID
+		}
+		target.Utime = time.Now().Unix()
+
+		// source 没有激活的两步验证密钥、恢复码和重置密码链接都跟着 source 一起删除
+		for _, m := range []any{&UserMFA{}, &MFARecoveryCode{}, &PasswordResetToken{}} {
+			if err := tx.Delete(m, "uid = ?", sourceID).Error; err != nil {
+				return err
+			}
+		}
+		// 先删除 source，释放唯一索引
+		if err := tx.Delete(&UserProfile{}, "uid = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&User{}, "id = ?", sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(&target).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UserProfile{}).Where("uid = ?", targetID).Updates(map[string]any{
+			"email":        target.Email.String,
+			"phone_number": target.Phone.String,
+		}).Error
+	})
+}
+
+// isUniqueConflict 判断是否违反了唯一索引
+func isUniqueConflict(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	const uniqueConflictErr uint16 = 1062
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == uniqueConflictErr
 }
@@ -0,0 +1,171 @@
+package dao
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGORMUserDAO_Merge(t *testing.T) {
+	cols := []string{"id", "email", "phone", "wechat_open_id"}
+	lockUsers := func(mock sqlmock.Sqlmock, target, source *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE id = ?")).
+			WithArgs(int64(1), 1).
+			WillReturnRows(target)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `users` WHERE id = ?")).
+			WithArgs(int64(2), 1).
+			WillReturnRows(source)
+	}
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "两个账号都有邮箱",
+			mock: func(mock sqlmock.Sqlmock) {
+				lockUsers(mock,
+					sqlmock.NewRows(cols).AddRow(1, "a@qq.com", nil, nil),
+					sqlmock.NewRows(cols).AddRow(2, "b@qq.com", "13800000000", nil))
+				// 什么都不改
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserMergeConflict,
+		},
+		{
+			name: "两个账号都有微信",
+			mock: func(mock sqlmock.Sqlmock) {
+				lockUsers(mock,
+					sqlmock.NewRows(cols).AddRow(1, "a@qq.com", nil, "open1"),
+					sqlmock.NewRows(cols).AddRow(2, nil, "13800000000", "open2"))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserMergeConflict,
+		},
+		{
+			name: "被合并的账号开启了两步验证",
+			mock: func(mock sqlmock.Sqlmock) {
+				lockUsers(mock,
+					sqlmock.NewRows(cols).AddRow(1, "a@qq.com", nil, nil),
+					sqlmock.NewRows(cols).AddRow(2, nil, "13800000000", nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `user_mfas` WHERE uid = ? AND enabled = ?")).
+					WithArgs(int64(2), true).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserMergeConflict,
+		},
+		{
+			name: "合并成功",
+			mock: func(mock sqlmock.Sqlmock) {
+				lockUsers(mock,
+					sqlmock.NewRows(cols).AddRow(1, "a@qq.com", nil, nil),
+					sqlmock.NewRows(cols).AddRow(2, nil, "13800000000", nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `user_mfas`")).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+				// source 的两步验证和重置密码记录不能留下
+				for _, table := range []string{"user_mfas", "mfa_recovery_codes", "password_reset_tokens",
+					"user_profiles"} {
+					mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `" + table + "` WHERE uid = ?")).
+						WithArgs(int64(2)).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `users` WHERE id = ?")).
+					WithArgs(int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `users` SET")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `user_profiles` SET")).
+					WithArgs("a@qq.com", "13800000000", int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			tc.mock(mock)
+
+			err := NewUserDAO(db, false).Merge(context.Background(), 1, 2)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGORMUserDAO_UpdateProfile(t *testing.T) {
+	summary := "hello"
+	testCases := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		want    UserProfile
+		wantErr error
+	}{
+		{
+			name: "版本号一致",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `user_profiles` SET `summary`=?,`utime`=?,`version`=version + 1 "+
+					"WHERE uid = ? AND version = ?")).
+					WithArgs("hello", sqlmock.AnyArg(), int64(123), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_profiles` WHERE uid = ?")).
+					WithArgs(int64(123), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "summary", "version"}).AddRow(1, 123, "hello", 2))
+				mock.ExpectCommit()
+			},
+			want: UserProfile{Id: 1, UID: 123, Summary: "hello", Version: 2},
+		},
+		{
+			name: "版本号已经变了",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `user_profiles` SET `summary`=?,`utime`=?,`version`=version + 1 "+
+					"WHERE uid = ? AND version = ?")).
+					WithArgs("hello", sqlmock.AnyArg(), int64(123), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				// 档案存在，只是没有修改
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_profiles` WHERE uid = ?")).
+					WithArgs(int64(123), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "uid", "summary", "version"}).AddRow(1, 123, "world", 3))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserProfileConflict,
+		},
+		{
+			name: "档案不存在",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `user_profiles`")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `user_profiles` WHERE uid = ?")).
+					WithArgs(int64(123), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: ErrUserProfileNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			tc.mock(mock)
+
+			up, err := NewUserDAO(db, false).UpdateProfile(context.Background(), UserProfileUpdate{
+				UID:     123,
+				Version: 1,
+				Summary: &summary,
+			})
+			assert.ErrorIs(t, err, tc.wantErr)
+			if tc.wantErr == nil {
+				assert.Equal(t, tc.want, up)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
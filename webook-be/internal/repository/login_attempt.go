@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=login_attempt.go -package=repomocks -destination=mocks/login_attempt.mock.go LoginAttemptRepository
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, email, ip string) (domain.LoginAttempts, error)
+	// RecordFailure 记录一次失败，返回记录后的统计
+	RecordFailure(ctx context.Context, email, ip string) (domain.LoginAttempts, error)
+	// Reset 登录成功后清空邮箱的失败次数
+	Reset(ctx context.Context, email string) error
+}
+
+type loginAttemptRepository struct {
+	cache cache.LoginAttemptCache
+}
+
+func NewLoginAttemptRepository(c cache.LoginAttemptCache) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		cache: c,
+	}
+}
+
+func (r *loginAttemptRepository) Get(ctx context.Context, email, ip string) (domain.LoginAttempts, error) {
+	return r.cache.Get(ctx, email, ip)
+}
+
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, email, ip string) (domain.LoginAttempts, error) {
+	return r.cache.RecordFailure(ctx, email, ip)
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, email string) error {
+	return r.cache.Reset(ctx, email)
+}
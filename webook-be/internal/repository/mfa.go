@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+var (
+	ErrMFANotEnabled     = errs.New(errs.UserMFANotEnabled, "没有开启两步验证")
+	ErrMFAAlreadyEnabled = errs.New(errs.UserMFAAlreadyEnabled, "已经开启了两步验证")
+	ErrMFATokenInvalid   = errs.New(errs.UserMFATokenInvalid, "登录已过期，请重新登录")
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=mfa.go -package=repomocks -destination=mocks/mfa.mock.go MFARepository
+type MFARepository interface {
+	// FindByUID 没有开启过两步验证时返回 ErrMFANotEnabled
+	FindByUID(ctx context.Context, uid int64) (domain.MFA, error)
+	// SavePending 保存还没有激活的密钥，已经激活时返回 ErrMFAAlreadyEnabled
+	SavePending(ctx context.Context, uid int64, secret string) error
+	// Enable 激活两步验证并替换恢复码，没有待激活的密钥时返回 ErrMFANotEnabled
+	Enable(ctx context.Context, uid int64, step int64, codeHashes []string) error
+	// UpdateLastStep step 已经使用过时返回 false
+	UpdateLastStep(ctx context.Context, uid int64, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error)
+	Delete(ctx context.Context, uid int64) error
+
+	// 以下是等待两步验证的登录，不存在或者已经过期时返回 ErrMFATokenInvalid
+
+	SetLoginPending(ctx context.Context, token string, p domain.MFALoginPending) error
+	GetLoginPending(ctx context.Context, token string) (domain.MFALoginPending, error)
+	// DeleteLoginPending token 已经被删除时返回 false
+	DeleteLoginPending(ctx context.Context, token string) (bool, error)
+
+	// 以下按用户统计两步验证的错误次数，换一个 token 也不会清空
+
+	// RecordLoginFailure 记录一次验证码错误，达到上限时锁定，返回锁定时间，没有锁定时返回 0
+	RecordLoginFailure(ctx context.Context, uid int64) (time.Duration, error)
+	// LoginLockTTL 返回两步验证还要锁定多久，没有锁定时返回 0
+	LoginLockTTL(ctx context.Context, uid int64) (time.Duration, error)
+	ResetLoginFailures(ctx context.Context, uid int64) error
+}
+
+type mfaRepository struct {
+	dao   dao.MFADAO
+	cache cache.MFALoginCache
+}
+
+func NewMFARepository(d dao.MFADAO, c cache.MFALoginCache) MFARepository {
+	return &mfaRepository{
+		dao:   d,
+		cache: c,
+	}
+}
+
+func (r *mfaRepository) FindByUID(ctx context.Context, uid int64) (domain.MFA, error) {
+	m, err := r.dao.FindByUID(ctx, uid)
+	if errors.Is(err, dao.ErrMFANotFound) {
+		return domain.MFA{}, ErrMFANotEnabled
+	}
+	if err != nil {
+		return domain.MFA{}, err
+	}
+	return domain.MFA{
+		UID:      m.UID,
+		Secret:   m.Secret,
+		Enabled:  m.Enabled,
+		LastStep: m.LastStep,
+	}, nil
+}
+
+func (r *mfaRepository) SavePending(ctx context.Context, uid int64, secret string) error {
+	err := r.dao.SavePending(ctx, uid, secret)
+	if errors.Is(err, dao.ErrMFAAlreadyEnabled) {
+		return ErrMFAAlreadyEnabled
+	}
+	return err
+}
+
+func (r *mfaRepository) Enable(ctx context.Context, uid int64, step int64, codeHashes []string) error {
+	err := r.dao.Enable(ctx, uid, step, codeHashes)
+	if errors.Is(err, dao.ErrMFANotFound) {
+		return ErrMFANotEnabled
+	}
+	return err
+}
+
+func (r *mfaRepository) UpdateLastStep(ctx context.Context, uid int64, step int64) (bool, error) {
+	return r.dao.UpdateLastStep(ctx, uid, step)
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error) {
+	return r.dao.UseRecoveryCode(ctx, uid, codeHash)
+}
+
+func (r *mfaRepository) Delete(ctx context.Context, uid int64) error {
+	return r.dao.Delete(ctx, uid)
+}
+
+func (r *mfaRepository) SetLoginPending(ctx context.Context, token string, p domain.MFALoginPending) error {
+	return r.cache.Set(ctx, token, p)
+}
+
+func (r *mfaRepository) GetLoginPending(ctx context.Context, token string) (domain.MFALoginPending, error) {
+	p, err := r.cache.Get(ctx, token)
+	if errors.Is(err, cache.ErrMFALoginNotFound) {
+		return domain.MFALoginPending{}, ErrMFATokenInvalid
+	}
+	return p, err
+}
+
+func (r *mfaRepository) DeleteLoginPending(ctx context.Context, token string) (bool, error) {
+	return r.cache.Delete(ctx, token)
+}
+
+func (r *mfaRepository) RecordLoginFailure(ctx context.Context, uid int64) (time.Duration, error) {
+	return r.cache.RecordFailure(ctx, uid)
+}
+
+func (r *mfaRepository) LoginLockTTL(ctx context.Context, uid int64) (time.Duration, error) {
+	return r.cache.LockTTL(ctx, uid)
+}
+
+func (r *mfaRepository) ResetLoginFailures(ctx context.Context, uid int64) error {
+	return r.cache.ResetFailures(ctx, uid)
+}
@@ -0,0 +1,218 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: mfa.go
+//
+// Generated by this command:
+//
+//	mockgen -source=mfa.go -package=repomocks -destination=mocks/mfa.mock.go MFARepository
+//
+
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/newton-miku/webook/webook-be/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFARepository is a mock of MFARepository interface.
+type MockMFARepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFARepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMFARepositoryMockRecorder is the mock recorder for MockMFARepository.
+type MockMFARepositoryMockRecorder struct {
+	mock *MockMFARepository
+}
+
+// NewMockMFARepository creates a new mock instance.
+func NewMockMFARepository(ctrl *gomock.Controller) *MockMFARepository {
+	mock := &MockMFARepository{ctrl: ctrl}
+	mock.recorder = &MockMFARepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFARepository) EXPECT() *MockMFARepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockMFARepository) Delete(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMFARepositoryMockRecorder) Delete(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMFARepository)(nil).Delete), ctx, uid)
+}
+
+// DeleteLoginPending mocks base method.
+func (m *MockMFARepository) DeleteLoginPending(ctx context.Context, token string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginPending", ctx, token)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLoginPending indicates an expected call of DeleteLoginPending.
+func (mr *MockMFARepositoryMockRecorder) DeleteLoginPending(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginPending", reflect.TypeOf((*MockMFARepository)(nil).DeleteLoginPending), ctx, token)
+}
+
+// Enable mocks base method.
+func (m *MockMFARepository) Enable(ctx context.Context, uid, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enable", ctx, uid, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enable indicates an expected call of Enable.
+func (mr *MockMFARepositoryMockRecorder) Enable(ctx, uid, step, codeHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enable", reflect.TypeOf((*MockMFARepository)(nil).Enable), ctx, uid, step, codeHashes)
+}
+
+// FindByUID mocks base method.
+func (m *MockMFARepository) FindByUID(ctx context.Context, uid int64) (domain.MFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUID", ctx, uid)
+	ret0, _ := ret[0].(domain.MFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUID indicates an expected call of FindByUID.
+func (mr *MockMFARepositoryMockRecorder) FindByUID(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUID", reflect.TypeOf((*MockMFARepository)(nil).FindByUID), ctx, uid)
+}
+
+// GetLoginPending mocks base method.
+func (m *MockMFARepository) GetLoginPending(ctx context.Context, token string) (domain.MFALoginPending, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginPending", ctx, token)
+	ret0, _ := ret[0].(domain.MFALoginPending)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginPending indicates an expected call of GetLoginPending.
+func (mr *MockMFARepositoryMockRecorder) GetLoginPending(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginPending", reflect.TypeOf((*MockMFARepository)(nil).GetLoginPending), ctx, token)
+}
+
+// LoginLockTTL mocks base method.
+func (m *MockMFARepository) LoginLockTTL(ctx context.Context, uid int64) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginLockTTL", ctx, uid)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginLockTTL indicates an expected call of LoginLockTTL.
+func (mr *MockMFARepositoryMockRecorder) LoginLockTTL(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginLockTTL", reflect.TypeOf((*MockMFARepository)(nil).LoginLockTTL), ctx, uid)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockMFARepository) RecordLoginFailure(ctx context.Context, uid int64) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, uid)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockMFARepositoryMockRecorder) RecordLoginFailure(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockMFARepository)(nil).RecordLoginFailure), ctx, uid)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockMFARepository) ResetLoginFailures(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockMFARepositoryMockRecorder) ResetLoginFailures(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockMFARepository)(nil).ResetLoginFailures), ctx, uid)
+}
+
+// SavePending mocks base method.
+func (m *MockMFARepository) SavePending(ctx context.Context, uid int64, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePending", ctx, uid, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePending indicates an expected call of SavePending.
+func (mr *MockMFARepositoryMockRecorder) SavePending(ctx, uid, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePending", reflect.TypeOf((*MockMFARepository)(nil).SavePending), ctx, uid, secret)
+}
+
+// SetLoginPending mocks base method.
+func (m *MockMFARepository) SetLoginPending(ctx context.Context, token string, p domain.MFALoginPending) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLoginPending", ctx, token, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLoginPending indicates an expected call of SetLoginPending.
+func (mr *MockMFARepositoryMockRecorder) SetLoginPending(ctx, token, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLoginPending", reflect.TypeOf((*MockMFARepository)(nil).SetLoginPending), ctx, token, p)
+}
+
+// UpdateLastStep mocks base method.
+func (m *MockMFARepository) UpdateLastStep(ctx context.Context, uid, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastStep", ctx, uid, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLastStep indicates an expected call of UpdateLastStep.
+func (mr *MockMFARepositoryMockRecorder) UpdateLastStep(ctx, uid, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastStep", reflect.TypeOf((*MockMFARepository)(nil).UpdateLastStep), ctx, uid, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, uid int64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, uid, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFARepositoryMockRecorder) UseRecoveryCode(ctx, uid, codeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFARepository)(nil).UseRecoveryCode), ctx, uid, codeHash)
+}
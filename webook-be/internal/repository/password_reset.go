@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+var ErrResetTokenInvalid = errs.New(errs.UserResetTokenInvalid, "重置链接无效或已过期，请重新申请")
+
+//go:generate go run go.uber.org/mock/mockgen -source=password_reset.go -package=repomocks -destination=mocks/password_reset.mock.go PasswordResetRepository
+type PasswordResetRepository interface {
+	Create(ctx context.Context, uid int64, tokenHash string, expireAt time.Time) error
+	// FindUID 返回有效 token 对应的用户，不会使用 token
+	FindUID(ctx context.Context, tokenHash string) (int64, error)
+	// Reset 使用 token 并把密码改为 hash，已经用过或者已经过期时返回 ErrResetTokenInvalid
+	Reset(ctx context.Context, tokenHash string, hash []byte) error
+}
+
+type passwordResetRepository struct {
+	dao dao.PasswordResetDAO
+}
+
+func NewPasswordResetRepository(d dao.PasswordResetDAO) PasswordResetRepository {
+	return &passwordResetRepository{
+		dao: d,
+	}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, uid int64, tokenHash string, expireAt time.Time) error {
+	return r.dao.Insert(ctx, dao.PasswordResetToken{
+		UID:       uid,
+		TokenHash: tokenHash,
+		ExpireAt:  expireAt.Unix(),
+	})
+}
+
+func (r *passwordResetRepository) FindUID(ctx context.Context, tokenHash string) (int64, error) {
+	t, err := r.dao.FindValid(ctx, tokenHash, time.Now().Unix())
+	if errors.Is(err, dao.ErrResetTokenNotFound) {
+		return 0, ErrResetTokenInvalid
+	}
+	return t.UID, err
+}
+
+func (r *passwordResetRepository) Reset(ctx context.Context, tokenHash string, hash []byte) error {
+	err := r.dao.Reset(ctx, tokenHash, string(hash), time.Now().Unix())
+	if errors.Is(err, dao.ErrResetTokenNotFound) {
+		return ErrResetTokenInvalid
+	}
+	return err
+}
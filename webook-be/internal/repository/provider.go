@@ -0,0 +1,13 @@
+package repository
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(
+	NewUserRepository,
+	NewCodeRepository,
+	NewSessionRepository,
+	NewAsyncSmsRepository,
+	NewPasswordResetRepository,
+	NewLoginAttemptRepository,
+	NewMFARepository,
+)
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+)
+
+type SessionRepository struct {
+	cache *cache.SessionCache
+}
+
+func NewSessionRepository(c *cache.SessionCache) *SessionRepository {
+	return &SessionRepository{
+		cache: c,
+	}
+}
+
+func (r *SessionRepository) Save(ctx context.Context, s domain.Session) error {
+	return r.cache.Set(ctx, s)
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, uid int64, ssid string, lastSeen int64,
+	minInterval time.Duration) error {
+	return r.cache.Touch(ctx, uid, ssid, lastSeen, minInterval)
+}
+
+// RotateRefresh oldID 不是当前的 refresh token 时返回 false
+func (r *SessionRepository) RotateRefresh(ctx context.Context, ssid, oldID, newID string) (bool, error) {
+	return r.cache.RotateRefresh(ctx, ssid, oldID, newID)
+}
+
+func (r *SessionRepository) FindByUid(ctx context.Context, uid int64) ([]domain.Session, error) {
+	return r.cache.List(ctx, uid)
+}
+
+func (r *SessionRepository) Delete(ctx context.Context, uid int64, ssids ...string) error {
+	return r.cache.Delete(ctx, uid, ssids...)
+}
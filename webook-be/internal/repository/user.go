@@ -2,70 +2,342 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
 	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
-	ErrUserDuplicateEmail  = dao.ErrUserDuplicateEmail
-	ErrUserNotFound        = dao.ErrUserNotFound
-	ErrUserProfileNotFound = dao.ErrUserProfileNotFound
+	ErrUserDuplicateEmail    = errs.New(errs.UserDuplicateEmail, "该邮箱已被注册")
+	ErrUserDuplicatePhone    = errs.New(errs.UserDuplicatePhone, "该手机号已被注册")
+	ErrUserDuplicateWechat   = errs.New(errs.UserDuplicateWechat, "该微信已被注册")
+	ErrUserDuplicateNickname = errs.New(errs.UserDuplicateNickname, "该昵称已被使用")
+	ErrUserNotFound          = errs.New(errs.UserNotFound, "用户不存在")
+	ErrUserProfileConflict   = errs.New(errs.UserProfileConflict, "档案已在其它地方修改，请刷新后重试")
+	ErrUserMergeConflict     = errs.New(errs.UserMergeConflict, "两个账号绑定了同一种登录方式或者另一个账号开启了两步验证，无法合并")
+	// 档案和用户一一对应，档案不存在就是用户不存在
+	ErrUserProfileNotFound = ErrUserNotFound
 )
 
-type UserRepository struct {
-	dao *dao.UserDAO
+//go:generate go run go.uber.org/mock/mockgen -source=user.go -package=repomocks -destination=mocks/user.mock.go UserRepository
+type UserRepository interface {
+	Create(ctx context.Context, u domain.User) error
+	FindByEmail(ctx context.Context, email string) (domain.User, error)
+	FindByPhone(ctx context.Context, phone string) (domain.User, error)
+	// FindByWechat 优先按 unionid 查找，没有 unionid 时按 openid 查找
+	FindByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error)
+	FindByID(ctx context.Context, id int64) (domain.User, error)
+	FindProfileByID(ctx context.Context, uid int64) (domain.UserProfile, error)
+	// UpdateProfile 只修改不为 nil 的字段，返回修改后的档案
+	// 档案的版本号不是 p.Version 时返回 ErrUserProfileConflict
+	UpdateProfile(ctx context.Context, p domain.UserProfilePatch) (domain.UserProfile, error)
+	UpdateAvatar(ctx context.Context, uid int64, avatarURL string) error
+	BindPhone(ctx context.Context, uid int64, phone string) error
+	UnbindPhone(ctx context.Context, uid int64) error
+	BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error
+	UnbindWechat(ctx context.Context, uid int64) error
+	// Merge 把 source 账号合并到 target 账号，两个账号有同一种登录方式或者 source 开启了两步验证时返回 ErrUserMergeConflict
+	Merge(ctx context.Context, targetID, sourceID int64) error
+	// UpdatePassword hash 为加密后的密码
+	UpdatePassword(ctx context.Context, uid int64, hash []byte) error
+	// MarkEmailVerified email 不是用户当前的邮箱时返回 ErrUserNotFound
+	MarkEmailVerified(ctx context.Context, uid int64, email string) error
 }
 
-func (r *UserRepository) UpdateProfile(ctx *gin.Context, u domain.UserProfile) error {
-	return r.dao.UpdateProfile(ctx, dao.UserProfile{
-		Id:          u.Id,
-		UID:         u.UID,
-		Nickname:    u.Nickname,
-		Email:       u.Email,
-		PhoneNumber: u.Phone,
-		Summary:     u.Summary,
-		Birthday:    u.Birthday,
+// CachedUserRepository 用户档案优先从缓存中读取
+type CachedUserRepository struct {
+	dao   dao.UserDAO
+	cache cache.UserCache
+	// 同一个用户的档案同时只从数据库加载一次
+	sfg singleflight.Group
+}
+
+// UpdateProfile 更新后删除缓存，下次读取时再从数据库加载
+func (r *CachedUserRepository) UpdateProfile(ctx context.Context, p domain.UserProfilePatch) (domain.UserProfile, error) {
+	up := dao.UserProfileUpdate{
+		UID:      p.UID,
+		Version:  p.Version,
+		Nickname: p.Nickname,
+		Summary:  p.Summary,
+	}
+	if p.Birthday != nil {
+		birthday, err := toNullDate(*p.Birthday)
+		if err != nil {
+			return domain.UserProfile{}, err
+		}
+		up.Birthday = &birthday
+	}
+	u, err := r.dao.UpdateProfile(ctx, up)
+	if err != nil {
+		return domain.UserProfile{}, toBizErr(err)
+	}
+	r.evictProfile(ctx, p.UID)
+	return r.profileToDomain(u), nil
+}
+
+func (r *CachedUserRepository) UpdateAvatar(ctx context.Context, uid int64, avatarURL string) error {
+	if err := r.dao.UpdateAvatar(ctx, uid, avatarURL); err != nil {
+		return toBizErr(err)
+	}
+	r.evictProfile(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) FindProfileByID(ctx context.Context, uid int64) (domain.UserProfile, error) {
+	p, err := r.cache.Get(ctx, uid)
+	switch {
+	case err == nil:
+		return p, nil
+	case errors.Is(err, cache.ErrUserNotFound):
+		return domain.UserProfile{}, ErrUserProfileNotFound
+	case !errors.Is(err, cache.ErrKeyNotExist):
+		// 缓存出错时直接查数据库
+		slog.Warn("读取用户档案缓存失败", slog.Int64("uid", uid), slog.Any("err", err))
+	}
+	val, err, _ := r.sfg.Do(strconv.FormatInt(uid, 10), func() (any, error) {
+		// 多个请求共用这次加载，不能因为第一个请求取消了就失败
+		return r.loadProfile(context.WithoutCancel(ctx), uid)
 	})
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	return val.(domain.UserProfile), nil
 }
 
-func (r *UserRepository) FindProfileByID(ctx context.Context, uid int64) (domain.UserProfile, error) {
+// loadProfile 从数据库加载档案并写入缓存，用户不存在时也会缓存下来
+func (r *CachedUserRepository) loadProfile(ctx context.Context, uid int64) (domain.UserProfile, error) {
 	u, err := r.dao.FindProfileByID(ctx, uid)
+	if errors.Is(err, dao.ErrUserNotFound) {
+		if err = r.cache.SetNotFound(ctx, uid); err != nil {
+			slog.Warn("写入用户不存在缓存失败", slog.Int64("uid", uid), slog.Any("err", err))
+		}
+		return domain.UserProfile{}, ErrUserProfileNotFound
+	}
 	if err != nil {
-		return domain.UserProfile{}, err
+		return domain.UserProfile{}, toBizErr(err)
+	}
+	p := r.profileToDomain(u)
+	if err = r.cache.Set(ctx, p); err != nil {
+		slog.Warn("写入用户档案缓存失败", slog.Int64("uid", uid), slog.Any("err", err))
 	}
+	return p, nil
+}
+
+func (r *CachedUserRepository) profileToDomain(u dao.UserProfile) domain.UserProfile {
 	return domain.UserProfile{
-		Id:       u.Id,
-		UID:      u.UID,
-		Email:    u.Email,
-		Nickname: u.Nickname,
-		Birthday: u.Birthday,
-		Phone:    u.PhoneNumber,
-		Summary:  u.Summary,
-	}, nil
-}
-func (r *UserRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
+		Id:        u.Id,
+		UID:       u.UID,
+		Email:     u.Email,
+		Nickname:  u.Nickname,
+		Birthday:  fromNullDate(u.Birthday),
+		Phone:     u.PhoneNumber,
+		Summary:   u.Summary,
+		AvatarURL: u.AvatarURL,
+		Version:   u.Version,
+		Utime:     u.Utime,
+	}
+}
+
+// evictProfile 档案修改后删除缓存，删除失败时只能等缓存过期
+func (r *CachedUserRepository) evictProfile(ctx context.Context, uids ...int64) {
+	for _, uid := range uids {
+		if err := r.cache.Delete(ctx, uid); err != nil {
+			slog.Error("删除用户档案缓存失败", slog.Int64("uid", uid), slog.Any("err", err))
+		}
+	}
+}
+
+func (r *CachedUserRepository) FindByEmail(ctx context.Context, email string) (domain.User, error) {
 	u, err := r.dao.FindByEmail(ctx, email)
 	if err != nil {
-		return domain.User{}, err
+		return domain.User{}, toBizErr(err)
 	}
-	return domain.User{
-		Id:       u.Id,
-		Email:    u.Email,
-		Password: []byte(u.Password),
-	}, nil
+	return r.toDomain(u), nil
 }
 
-func NewUserRepository(dao *dao.UserDAO) *UserRepository {
-	return &UserRepository{
-		dao: dao,
+func (r *CachedUserRepository) FindByPhone(ctx context.Context, phone string) (domain.User, error) {
+	u, err := r.dao.FindByPhone(ctx, phone)
+	if err != nil {
+		return domain.User{}, toBizErr(err)
 	}
+	return r.toDomain(u), nil
 }
 
-func (r *UserRepository) Create(ctx context.Context, u domain.User) error {
-	return r.dao.Insert(ctx, dao.User{
-		Email:    u.Email,
-		Password: string(u.Password),
-	})
+func NewUserRepository(dao dao.UserDAO, c cache.UserCache) UserRepository {
+	return &CachedUserRepository{
+		dao:   dao,
+		cache: c,
+	}
+}
+
+// FindByWechat 优先按 unionid 查找，没有 unionid 时按 openid 查找
+func (r *CachedUserRepository) FindByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error) {
+	var (
+		u   dao.User
+		err error
+	)
+	if info.UnionID != "" {
+		u, err = r.dao.FindByWechatUnionID(ctx, info.UnionID)
+	} else {
+		u, err = r.dao.FindByWechatOpenID(ctx, info.OpenID)
+	}
+	if err != nil {
+		return domain.User{}, toBizErr(err)
+	}
+	return r.toDomain(u), nil
+}
+
+func (r *CachedUserRepository) FindByID(ctx context.Context, id int64) (domain.User, error) {
+	u, err := r.dao.FindByID(ctx, id)
+	if err != nil {
+		return domain.User{}, toBizErr(err)
+	}
+	return r.toDomain(u), nil
+}
+
+// BindPhone 档案中也有手机号，需要删除缓存
+func (r *CachedUserRepository) BindPhone(ctx context.Context, uid int64, phone string) error {
+	if err := r.dao.BindPhone(ctx, uid, phone); err != nil {
+		return toBizErr(err)
+	}
+	r.evictProfile(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) UnbindPhone(ctx context.Context, uid int64) error {
+	if err := r.dao.UnbindPhone(ctx, uid); err != nil {
+		return toBizErr(err)
+	}
+	r.evictProfile(ctx, uid)
+	return nil
+}
+
+func (r *CachedUserRepository) BindWechat(ctx context.Context, uid int64, info domain.WechatInfo) error {
+	return toBizErr(r.dao.BindWechat(ctx, uid,
+		sql.NullString{String: info.OpenID, Valid: info.OpenID != ""},
+		sql.NullString{String: info.UnionID, Valid: info.UnionID != ""}))
+}
+
+func (r *CachedUserRepository) UnbindWechat(ctx context.Context, uid int64) error {
+	return r.dao.UnbindWechat(ctx, uid)
+}
+
+// Merge 把 source 账号合并到 target 账号
+func (r *CachedUserRepository) Merge(ctx context.Context, targetID, sourceID int64) error {
+	if err := r.dao.Merge(ctx, targetID, sourceID); err != nil {
+		return toBizErr(err)
+	}
+	r.evictProfile(ctx, targetID, sourceID)
+	return nil
+}
+
+// UpdatePassword 档案中没有密码，不需要删除缓存
+func (r *CachedUserRepository) UpdatePassword(ctx context.Context, uid int64, hash []byte) error {
+	return r.dao.UpdatePassword(ctx, uid, string(hash))
+}
+
+func (r *CachedUserRepository) MarkEmailVerified(ctx context.Context, uid int64, email string) error {
+	return toBizErr(r.dao.MarkEmailVerified(ctx, uid, email))
+}
+
+// Create 新用户的 id 可能被当作不存在的用户缓存过，创建后需要删除
+func (r *CachedUserRepository) Create(ctx context.Context, u domain.User) error {
+	id, err := r.dao.Insert(ctx, r.toEntity(u))
+	if err != nil {
+		return toBizErr(err)
+	}
+	r.evictProfile(ctx, id)
+	return nil
+}
+
+// toBizErr 把 dao 的错误转换为业务错误，其它错误原样返回
+func toBizErr(err error) error {
+	switch {
+	case errors.Is(err, dao.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, dao.ErrUserDuplicateEmail):
+		return ErrUserDuplicateEmail
+	case errors.Is(err, dao.ErrUserDuplicatePhone):
+		return ErrUserDuplicatePhone
+	case errors.Is(err, dao.ErrUserDuplicateWechat):
+		return ErrUserDuplicateWechat
+	case errors.Is(err, dao.ErrUserDuplicateNickname):
+		return ErrUserDuplicateNickname
+	case errors.Is(err, dao.ErrUserProfileConflict):
+		return ErrUserProfileConflict
+	case errors.Is(err, dao.ErrUserMergeConflict):
+		return ErrUserMergeConflict
+	default:
+		return err
+	}
+}
+
+// 生日在数据库中是 DATE，领域对象中是 2006-01-02 格式的字符串
+const dateLayout = time.DateOnly
+
+// toNullDate 空字符串表示没有填写
+func toNullDate(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.ParseInLocation(dateLayout, s, time.UTC)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+func fromNullDate(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.UTC().Format(dateLayout)
+}
+
+func (r *CachedUserRepository) toDomain(u dao.User) domain.User {
+	return domain.User{
+		Id:            u.Id,
+		Email:         u.Email.String,
+		EmailVerified: u.EmailVerified,
+		Phone:         u.Phone.String,
+		Password:      []byte(u.Password),
+		WechatInfo: domain.WechatInfo{
+			OpenID:  u.WechatOpenID.String,
+			UnionID: u.WechatUnionID.String,
+		},
+		Ctime: u.Ctime,
+	}
+}
+
+func (r *CachedUserRepository) toEntity(u domain.User) dao.User {
+	return dao.User{
+		Id: u.Id,
+		Email: sql.NullString{
+			String: u.Email,
+			Valid:  u.Email != "",
+		},
+		Phone: sql.NullString{
+			String: u.Phone,
+			Valid:  u.Phone != "",
+		},
+		Password:      string(u.Password),
+		EmailVerified: u.EmailVerified,
+		WechatOpenID: sql.NullString{
+			String: u.WechatInfo.OpenID,
+			Valid:  u.WechatInfo.OpenID != "",
+		},
+		WechatUnionID: sql.NullString{
+			String: u.WechatInfo.UnionID,
+			Valid:  u.WechatInfo.UnionID != "",
+		},
+		Ctime: u.Ctime,
+	}
 }
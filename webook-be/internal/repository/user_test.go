@@ -0,0 +1,215 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	cachemocks "github.com/newton-miku/webook/webook-be/internal/repository/cache/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	daomocks "github.com/newton-miku/webook/webook-be/internal/repository/dao/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCachedUserRepository_FindProfileByID(t *testing.T) {
+	profile := domain.UserProfile{
+		Id:       1,
+		UID:      123,
+		Email:    "123@qq.com",
+		Nickname: "miku",
+		Birthday: "2000-01-01",
+		Summary:  "hello",
+	}
+	entity := dao.UserProfile{
+		Id:       1,
+		UID:      123,
+		Email:    "123@qq.com",
+		Nickname: "miku",
+		Birthday: sql.NullTime{Time: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+		Summary:  "hello",
+	}
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		want    domain.UserProfile
+		wantErr error
+	}{
+		{
+			name: "缓存命中",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(profile, nil)
+				return daomocks.NewMockUserDAO(ctrl), c
+			},
+			want: profile,
+		},
+		{
+			name: "缓存未命中，查询数据库并回写缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(domain.UserProfile{}, cache.ErrKeyNotExist)
+				c.EXPECT().Set(gomock.Any(), profile).Return(nil)
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(entity, nil)
+				return d, c
+			},
+			want: profile,
+		},
+		{
+			name: "缓存出错，回写缓存也失败，仍然返回数据库中的数据",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(domain.UserProfile{}, errors.New("redis 错误"))
+				c.EXPECT().Set(gomock.Any(), profile).Return(errors.New("redis 错误"))
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(entity, nil)
+				return d, c
+			},
+			want: profile,
+		},
+		{
+			name: "缓存了用户不存在",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(domain.UserProfile{}, cache.ErrUserNotFound)
+				return daomocks.NewMockUserDAO(ctrl), c
+			},
+			wantErr: repository.ErrUserProfileNotFound,
+		},
+		{
+			name: "用户不存在，缓存下来",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(domain.UserProfile{}, cache.ErrKeyNotExist)
+				c.EXPECT().SetNotFound(gomock.Any(), int64(123)).Return(nil)
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(dao.UserProfile{}, dao.ErrUserNotFound)
+				return d, c
+			},
+			wantErr: repository.ErrUserProfileNotFound,
+		},
+		{
+			name: "查询数据库失败",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Get(gomock.Any(), int64(123)).Return(domain.UserProfile{}, cache.ErrKeyNotExist)
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(dao.UserProfile{}, errors.New("db 错误"))
+				return d, c
+			},
+			wantErr: errors.New("db 错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := repository.NewUserRepository(tc.mock(ctrl))
+			p, err := repo.FindProfileByID(context.Background(), 123)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+}
+
+// 同一个用户的并发请求只查询一次数据库
+func TestCachedUserRepository_FindProfileByIDSingleflight(t *testing.T) {
+	const n = 10
+	ctrl := gomock.NewController(t)
+	var arrived sync.WaitGroup
+	arrived.Add(n)
+	c := cachemocks.NewMockUserCache(ctrl)
+	c.EXPECT().Get(gomock.Any(), int64(123)).DoAndReturn(func(context.Context, int64) (domain.UserProfile, error) {
+		arrived.Done()
+		return domain.UserProfile{}, cache.ErrKeyNotExist
+	}).Times(n)
+	c.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil)
+	d := daomocks.NewMockUserDAO(ctrl)
+	d.EXPECT().FindProfileByID(gomock.Any(), int64(123)).DoAndReturn(func(context.Context, int64) (dao.UserProfile, error) {
+		// 等所有请求都未命中缓存，再留一点时间让它们进入 singleflight
+		arrived.Wait()
+		time.Sleep(50 * time.Millisecond)
+		return dao.UserProfile{Id: 1, UID: 123}, nil
+	})
+	repo := repository.NewUserRepository(d, c)
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := repo.FindProfileByID(context.Background(), 123)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(123), p.UID)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCachedUserRepository_UpdateProfile(t *testing.T) {
+	nickname := "miku"
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache)
+		want    domain.UserProfile
+		wantErr error
+	}{
+		{
+			name: "更新成功后删除缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				// 没有传的生日不修改
+				d.EXPECT().UpdateProfile(gomock.Any(), dao.UserProfileUpdate{UID: 123, Version: 1, Nickname: &nickname}).
+					Return(dao.UserProfile{Id: 1, UID: 123, Nickname: "miku", Version: 2, Utime: 1700000000}, nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), int64(123)).Return(nil)
+				return d, c
+			},
+			want: domain.UserProfile{Id: 1, UID: 123, Nickname: "miku", Version: 2, Utime: 1700000000},
+		},
+		{
+			name: "删除缓存失败不影响结果",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Return(dao.UserProfile{UID: 123, Version: 2}, nil)
+				c := cachemocks.NewMockUserCache(ctrl)
+				c.EXPECT().Delete(gomock.Any(), int64(123)).Return(errors.New("redis 错误"))
+				return d, c
+			},
+			want: domain.UserProfile{UID: 123, Version: 2},
+		},
+		{
+			name: "版本号冲突",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Return(dao.UserProfile{}, dao.ErrUserProfileConflict)
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: repository.ErrUserProfileConflict,
+		},
+		{
+			name: "更新失败时不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.UserDAO, cache.UserCache) {
+				d := daomocks.NewMockUserDAO(ctrl)
+				d.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Return(dao.UserProfile{}, errors.New("db 错误"))
+				return d, cachemocks.NewMockUserCache(ctrl)
+			},
+			wantErr: errors.New("db 错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := repository.NewUserRepository(tc.mock(ctrl))
+			p, err := repo.UpdateProfile(context.Background(), domain.UserProfilePatch{UID: 123, Version: 1, Nickname: &nickname})
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.want, p)
+		})
+	}
+}
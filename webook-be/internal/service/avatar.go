@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/imagex"
+	"github.com/newton-miku/webook/webook-be/pkg/objstore"
+)
+
+var ErrAvatarFormat = errs.New(errs.InvalidParam, "头像只支持 JPEG、PNG、GIF 格式的图片")
+
+// 允许上传的格式，按文件内容判断，key 为 MIME 类型，value 为 image.Decode 返回的格式
+var avatarFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+//go:generate go run go.uber.org/mock/mockgen -source=avatar.go -package=svcmocks -destination=mocks/avatar.mock.go AvatarService
+type AvatarService interface {
+	// Upload 校验图片，从中间裁剪为正方形，按配置的尺寸重新编码为 JPEG 保存
+	// 返回最大尺寸的地址，同时更新到档案中
+	Upload(ctx context.Context, uid int64, r io.Reader) (string, error)
+}
+
+type AvatarOptions struct {
+	// 图片文件的大小上限
+	MaxBytes int64
+	// 宽和高的上限，避免很小的文件解码出很大的图片
+	MaxDimension int
+	// 生成的尺寸，单位为像素
+	Sizes []int
+}
+
+type avatarService struct {
+	repo  repository.UserRepository
+	store objstore.Store
+	opts  AvatarOptions
+}
+
+func NewAvatarService(repo repository.UserRepository, store objstore.Store, opts AvatarOptions) AvatarService {
+	// 从大到小，第一个是档案中的头像
+	opts.Sizes = slices.Clone(opts.Sizes)
+	slices.Sort(opts.Sizes)
+	slices.Reverse(opts.Sizes)
+	return &avatarService{
+		repo:  repo,
+		store: store,
+		opts:  opts,
+	}
+}
+
+func (svc *avatarService) Upload(ctx context.Context, uid int64, r io.Reader) (string, error) {
+	img, err := svc.decode(r)
+	if err != nil {
+		return "", err
+	}
+	// 确认用户存在，同时拿到旧头像，替换成功后删除
+	profile, err := svc.repo.FindProfileByID(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	// 每次上传使用新的目录，地址变化后浏览器和 CDN 的缓存自然失效
+	dir := fmt.Sprintf("avatars/%d/%s", uid, strings.ToLower(rand.Text()[:16]))
+	// 只裁剪和去掉透明一次，每个尺寸都从同一张图缩放
+	square := imagex.Flatten(img, imagex.CropSquare(img), color.White)
+	avatarURL, err := svc.save(ctx, uid, dir, square)
+	if err != nil {
+		// 新目录不会再被用到，已经上传的尺寸要删掉，请求被取消了也要删
+		svc.deleteDir(context.WithoutCancel(ctx), dir)
+		return "", err
+	}
+	svc.deleteOld(ctx, profile.AvatarURL)
+	return avatarURL, nil
+}
+
+// save 把每个尺寸上传到 dir 中，全部成功后更新用户的头像地址
+func (svc *avatarService) save(ctx context.Context, uid int64, dir string, square *image.RGBA) (string, error) {
+	for _, size := range svc.opts.Sizes {
+		var buf bytes.Buffer
+		dst := imagex.Resize(square, size, size)
+		// 重新编码同时去掉了 EXIF 等元数据
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return "", err
+		}
+		key := avatarKey(dir, size)
+		if err := svc.store.Put(ctx, key, &buf, int64(buf.Len()), "image/jpeg"); err != nil {
+			return "", err
+		}
+	}
+	avatarURL := svc.store.URL(avatarKey(dir, svc.opts.Sizes[0]))
+	if err := svc.repo.UpdateAvatar(ctx, uid, avatarURL); err != nil {
+		return "", err
+	}
+	return avatarURL, nil
+}
+
+// decode 按文件内容判断格式，不相信客户端给的 Content-Type 和扩展名
+func (svc *avatarService) decode(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, svc.opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > svc.opts.MaxBytes {
+		return nil, errs.New(errs.InvalidParam, fmt.Sprintf("头像不能超过 %dKB", svc.opts.MaxBytes>>10))
+	}
+	format, ok := avatarFormats[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrAvatarFormat
+	}
+	cfg, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return nil, ErrAvatarFormat
+	}
+	if cfg.Width > svc.opts.MaxDimension || cfg.Height > svc.opts.MaxDimension {
+		return nil, errs.New(errs.InvalidParam,
+			fmt.Sprintf("头像的宽和高不能超过 %d 像素", svc.opts.MaxDimension))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAvatarFormat
+	}
+	return img, nil
+}
+
+// deleteOld 删除旧头像的所有尺寸
+// 旧头像不是这个存储中的地址时不处理
+func (svc *avatarService) deleteOld(ctx context.Context, oldURL string) {
+	key, ok := strings.CutPrefix(oldURL, svc.store.URL(""))
+	if oldURL == "" || !ok {
+		return
+	}
+	svc.deleteDir(ctx, path.Dir(key))
+}
+
+// deleteDir 删除 dir 中所有尺寸的头像，失败时只记录日志
+func (svc *avatarService) deleteDir(ctx context.Context, dir string) {
+	for _, size := range svc.opts.Sizes {
+		key := avatarKey(dir, size)
+		if err := svc.store.Delete(ctx, key); err != nil {
+			slog.Error("删除头像失败", slog.String("key", key), slog.Any("err", err))
+		}
+	}
+}
+
+func avatarKey(dir string, size int) string {
+	return dir + "/" + strconv.Itoa(size) + ".jpg"
+}
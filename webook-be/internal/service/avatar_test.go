@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	repomocks "github.com/newton-miku/webook/webook-be/internal/repository/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	objstoremocks "github.com/newton-miku/webook/webook-be/pkg/objstore/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newAvatarService(ctrl *gomock.Controller) (service.AvatarService,
+	*repomocks.MockUserRepository, *objstoremocks.MockStore) {
+	repo := repomocks.NewMockUserRepository(ctrl)
+	store := objstoremocks.NewMockStore(ctrl)
+	store.EXPECT().URL(gomock.Any()).DoAndReturn(func(key string) string {
+		return "http://cdn.example.com/" + key
+	}).AnyTimes()
+	svc := service.NewAvatarService(repo, store, service.AvatarOptions{
+		MaxBytes:     64 << 10,
+		MaxDimension: 1000,
+		Sizes:        []int{64, 256},
+	})
+	return svc, repo, store
+}
+
+func TestAvatarService_Upload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc, repo, store := newAvatarService(ctrl)
+	repo.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(domain.UserProfile{
+		UID: 123, AvatarURL: "http://cdn.example.com/avatars/123/old/256.jpg",
+	}, nil)
+	sizes := map[string]image.Rectangle{}
+	store.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "image/jpeg").
+		DoAndReturn(func(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+			img, err := jpeg.Decode(r)
+			require.NoError(t, err)
+			sizes[key[strings.LastIndex(key, "/")+1:]] = img.Bounds()
+			return nil
+		}).Times(2)
+	var avatarURL string
+	repo.EXPECT().UpdateAvatar(gomock.Any(), int64(123), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int64, url string) error {
+			avatarURL = url
+			return nil
+		})
+	store.EXPECT().Delete(gomock.Any(), "avatars/123/old/256.jpg").Return(nil)
+	store.EXPECT().Delete(gomock.Any(), "avatars/123/old/64.jpg").Return(nil)
+
+	got, err := svc.Upload(context.Background(), 123, bytes.NewReader(encodePNG(t, 300, 200)))
+	require.NoError(t, err)
+	assert.Equal(t, avatarURL, got)
+	assert.Regexp(t, `^http://cdn.example.com/avatars/123/[a-z2-7]{16}/256.jpg$`, got)
+	assert.Equal(t, map[string]image.Rectangle{
+		"256.jpg": image.Rect(0, 0, 256, 256),
+		"64.jpg":  image.Rect(0, 0, 64, 64),
+	}, sizes)
+}
+
+func TestAvatarService_Upload_Failed(t *testing.T) {
+	testCases := []struct {
+		name string
+		// putFail 第几次上传失败，0 表示都成功
+		putFail   int
+		updateErr error
+	}{
+		{
+			name:    "上传到一半失败",
+			putFail: 2,
+		},
+		{
+			name:      "更新头像地址失败",
+			updateErr: errors.New("db error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc, repo, store := newAvatarService(ctrl)
+			repo.EXPECT().FindProfileByID(gomock.Any(), int64(123)).Return(domain.UserProfile{
+				UID: 123, AvatarURL: "http://cdn.example.com/avatars/123/old/256.jpg",
+			}, nil)
+			var dir string
+			var putCnt int
+			store.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "image/jpeg").
+				DoAndReturn(func(_ context.Context, key string, _ io.Reader, _ int64, _ string) error {
+					dir = key[:strings.LastIndex(key, "/")]
+					putCnt++
+					if putCnt == tc.putFail {
+						return errors.New("store error")
+					}
+					return nil
+				}).MinTimes(1)
+			if tc.putFail == 0 {
+				repo.EXPECT().UpdateAvatar(gomock.Any(), int64(123), gomock.Any()).Return(tc.updateErr)
+			}
+			// 新目录中的文件全部删掉，旧头像保留
+			var deleted []string
+			store.EXPECT().Delete(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, key string) error {
+					deleted = append(deleted, key)
+					return nil
+				}).Times(2)
+
+			_, err := svc.Upload(context.Background(), 123, bytes.NewReader(encodePNG(t, 300, 200)))
+			require.Error(t, err)
+			assert.ElementsMatch(t, []string{dir + "/64.jpg", dir + "/256.jpg"}, deleted)
+		})
+	}
+}
+
+func TestAvatarService_Upload_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    func(t *testing.T) []byte
+		wantMsg string
+	}{
+		{
+			name:    "不是图片",
+			data:    func(t *testing.T) []byte { return []byte("<html><body>hello</body></html>") },
+			wantMsg: "头像只支持 JPEG、PNG、GIF 格式的图片",
+		},
+		{
+			name:    "文件太大",
+			data:    func(t *testing.T) []byte { return make([]byte, 64<<10+1) },
+			wantMsg: "头像不能超过 64KB",
+		},
+		{
+			name:    "尺寸太大",
+			data:    func(t *testing.T) []byte { return encodePNG(t, 1001, 10) },
+			wantMsg: "头像的宽和高不能超过 1000 像素",
+		},
+		{
+			name: "内容和格式不符",
+			data: func(t *testing.T) []byte {
+				// PNG 的文件头，后面的内容是坏的
+				return append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 100)...)
+			},
+			wantMsg: "头像只支持 JPEG、PNG、GIF 格式的图片",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc, _, _ := newAvatarService(ctrl)
+			_, err := svc.Upload(context.Background(), 123, bytes.NewReader(tc.data(t)))
+			e, ok := errs.From(err)
+			require.True(t, ok)
+			assert.Equal(t, errs.InvalidParam, e.Code)
+			assert.Equal(t, tc.wantMsg, e.Msg)
+		})
+	}
+}
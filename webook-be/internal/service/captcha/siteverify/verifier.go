@@ -0,0 +1,56 @@
+package siteverify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Verifier 调用服务商的 siteverify 接口校验 token
+// reCAPTCHA、hCaptcha 和 Cloudflare Turnstile 的接口格式相同，换 verifyURL 即可
+type Verifier struct {
+	client    *http.Client
+	verifyURL string
+	secret    string
+}
+
+func NewVerifier(client *http.Client, verifyURL, secret string) *Verifier {
+	return &Verifier{
+		client:    client,
+		verifyURL: verifyURL,
+		secret:    secret,
+	}
+}
+
+type verifyResult struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (v *Verifier) Verify(ctx context.Context, token, ip string) (bool, error) {
+	form := url.Values{}
+	form.Set("secret", v.secret)
+	form.Set("response", token)
+	form.Set("remoteip", ip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("人机验证接口返回 %d", resp.StatusCode)
+	}
+	var res verifyResult
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return false, err
+	}
+	return res.Success, nil
+}
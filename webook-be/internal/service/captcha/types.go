@@ -0,0 +1,10 @@
+package captcha
+
+import "context"
+
+// Verifier 校验前端人机验证组件返回的 token
+//
+//go:generate go run go.uber.org/mock/mockgen -source=types.go -package=captchamocks -destination=mocks/types.mock.go Verifier
+type Verifier interface {
+	Verify(ctx context.Context, token, ip string) (bool, error)
+}
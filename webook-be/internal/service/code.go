@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+)
+
+var (
+	ErrCodeSendTooMany   = repository.ErrCodeSendTooMany
+	ErrCodeVerifyTooMany = repository.ErrCodeVerifyTooMany
+)
+
+// 验证码短信模板
+const codeTplId = "1877556"
+
+type CodeService struct {
+	repo   *repository.CodeRepository
+	smsSvc sms.Service
+}
+
+func NewCodeService(repo *repository.CodeRepository, smsSvc sms.Service) *CodeService {
+	return &CodeService{
+		repo:   repo,
+		smsSvc: smsSvc,
+	}
+}
+
+// Send 生成验证码并发送给 phone
+// biz 用于区分业务，不同业务的验证码互不影响
+func (svc *CodeService) Send(ctx context.Context, biz, phone string) error {
+	code, err := svc.generateCode()
+	if err != nil {
+		return err
+	}
+	err = svc.repo.Store(ctx, biz, phone, code)
+	if err != nil {
+		return err
+	}
+	return svc.smsSvc.Send(ctx, codeTplId, []string{code}, phone)
+}
+
+func (svc *CodeService) Verify(ctx context.Context, biz, phone, inputCode string) (bool, error) {
+	return svc.repo.Verify(ctx, biz, phone, inputCode)
+}
+
+// generateCode 验证码用于登录，不能被预测，所以使用 crypto/rand
+func (svc *CodeService) generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	// 6 位数字，不足的前面补 0
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+)
+
+var (
+	ErrEmailNotVerified        = errs.New(errs.UserEmailNotVerified, "邮箱尚未验证，请先点击验证邮件中的链接")
+	ErrEmailVerifyTokenInvalid = errs.New(errs.UserEmailVerifyTokenInvalid, "验证链接无效或已过期，请重新发送验证邮件")
+)
+
+// EmailVerifyRequirement 邮箱验证之前限制哪些操作
+type EmailVerifyRequirement string
+
+const (
+	// EmailVerifyNone 不限制
+	EmailVerifyNone EmailVerifyRequirement = "none"
+	// EmailVerifyLogin 不能用邮箱登录
+	EmailVerifyLogin EmailVerifyRequirement = "login"
+	// EmailVerifyPost 可以登录，但是不能发帖、修改档案和头像
+	EmailVerifyPost EmailVerifyRequirement = "post"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=email_verify.go -package=svcmocks -destination=mocks/email_verify.mock.go EmailVerifyService
+type EmailVerifyService interface {
+	// SendVerifyMail 给邮箱发送验证链接
+	// 邮箱没有注册或者已经验证过时直接返回 nil，同一个邮箱发送太频繁时返回 ErrMailSendTooMany
+	SendVerifyMail(ctx context.Context, email string) error
+	// Verify 校验邮件中的 token，把邮箱标记为已验证
+	Verify(ctx context.Context, token string) error
+	// CheckLogin 用邮箱登录时调用，邮箱未验证且配置为 login 时返回 ErrEmailNotVerified
+	CheckLogin(u domain.User) error
+	// CheckPost 发帖前调用，邮箱未验证且配置为 login 或者 post 时返回 ErrEmailNotVerified
+	// 没有邮箱的用户（手机号、微信注册）不受限制
+	CheckPost(ctx context.Context, uid int64) error
+}
+
+// emailVerifyClaims token 中带上邮箱，用户换了邮箱后旧链接自动失效
+type emailVerifyClaims struct {
+	jwt.RegisteredClaims
+	Email string
+}
+
+type emailVerifyService struct {
+	repo    repository.UserRepository
+	mailSvc mail.Service
+	// 按邮箱限制发送，为 nil 时不限流
+	limiter limiter.Limiter
+	require EmailVerifyRequirement
+	// 签名 token 的密钥
+	secret []byte
+	// 链接的有效期
+	expiration time.Duration
+	// 前端验证邮箱页面的地址
+	verifyURL string
+}
+
+func NewEmailVerifyService(repo repository.UserRepository, mailSvc mail.Service, l limiter.Limiter,
+	require EmailVerifyRequirement, secret []byte, expiration time.Duration, verifyURL string) EmailVerifyService {
+	return &emailVerifyService{
+		repo:       repo,
+		mailSvc:    mailSvc,
+		limiter:    l,
+		require:    require,
+		secret:     secret,
+		expiration: expiration,
+		verifyURL:  verifyURL,
+	}
+}
+
+func (svc *emailVerifyService) SendVerifyMail(ctx context.Context, email string) error {
+	if err := limitMail(ctx, svc.limiter, "verify", email); err != nil {
+		return err
+	}
+	u, err := svc.repo.FindByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return nil
+	}
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, emailVerifyClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(u.Id, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(svc.expiration)),
+		},
+		Email: u.Email,
+	}).SignedString(svc.secret)
+	if err != nil {
+		return err
+	}
+	link, err := url.Parse(svc.verifyURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	body := fmt.Sprintf("欢迎注册 webook，请在 %d 小时内打开下面的链接验证邮箱：\n\n%s\n\n如果不是你本人操作，请忽略这封邮件。",
+		int(svc.expiration.Hours()), link.String())
+	return svc.mailSvc.Send(ctx, u.Email, "验证 webook 邮箱", body)
+}
+
+// Verify 同一个链接可以重复打开，已经验证过也返回 nil
+func (svc *emailVerifyService) Verify(ctx context.Context, token string) error {
+	var claims emailVerifyClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return svc.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return ErrEmailVerifyTokenInvalid
+	}
+	uid, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return ErrEmailVerifyTokenInvalid
+	}
+	err = svc.repo.MarkEmailVerified(ctx, uid, claims.Email)
+	if errors.Is(err, ErrUserNotFound) {
+		// 用户已经注销，或者换了邮箱
+		return ErrEmailVerifyTokenInvalid
+	}
+	return err
+}
+
+func (svc *emailVerifyService) CheckLogin(u domain.User) error {
+	if svc.require == EmailVerifyLogin && u.Email != "" && !u.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+func (svc *emailVerifyService) CheckPost(ctx context.Context, uid int64) error {
+	if svc.require == EmailVerifyNone {
+		return nil
+	}
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Email != "" && !u.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
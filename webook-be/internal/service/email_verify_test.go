@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	repomocks "github.com/newton-miku/webook/webook-be/internal/repository/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	mailmocks "github.com/newton-miku/webook/webook-be/internal/service/mail/mocks"
+	limitermocks "github.com/newton-miku/webook/webook-be/pkg/limiter/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// sendAndGetToken 发送验证邮件，返回邮件链接中的 token
+func sendAndGetToken(t *testing.T, svc service.EmailVerifyService,
+	repo *repomocks.MockUserRepository, mailSvc *mailmocks.MockService) string {
+	repo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").
+		Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+	var body string
+	mailSvc.EXPECT().Send(gomock.Any(), "123@qq.com", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, to, subject, b string) error {
+			body = b
+			return nil
+		})
+	require.NoError(t, svc.SendVerifyMail(context.Background(), "123@qq.com"))
+	u, err := url.Parse(regexp.MustCompile(`http://\S+`).FindString(body))
+	require.NoError(t, err)
+	return u.Query().Get("token")
+}
+
+func TestEmailVerifyService_SendVerifyMail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	l := limitermocks.NewMockLimiter(ctrl)
+	l.EXPECT().Limit(gomock.Any(), "mail-limiter:verify:123@qq.com").Return(true, nil)
+	// 被限流时不查询邮箱，也不发邮件
+	svc := service.NewEmailVerifyService(repomocks.NewMockUserRepository(ctrl), mailmocks.NewMockService(ctrl), l,
+		service.EmailVerifyLogin, []byte("verify-secret"), time.Hour, "http://localhost:3000/users/verify_email")
+
+	assert.ErrorIs(t, svc.SendVerifyMail(context.Background(), "123@qq.com"), service.ErrMailSendTooMany)
+}
+
+func TestEmailVerifyService_Verify(t *testing.T) {
+	newSvc := func(ctrl *gomock.Controller, expiration time.Duration) (service.EmailVerifyService,
+		*repomocks.MockUserRepository, *mailmocks.MockService) {
+		repo := repomocks.NewMockUserRepository(ctrl)
+		mailSvc := mailmocks.NewMockService(ctrl)
+		svc := service.NewEmailVerifyService(repo, mailSvc, nil, service.EmailVerifyLogin,
+			[]byte("verify-secret"), expiration, "http://localhost:3000/users/verify_email")
+		return svc, repo, mailSvc
+	}
+
+	t.Run("验证成功", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, repo, mailSvc := newSvc(ctrl, time.Hour)
+		token := sendAndGetToken(t, svc, repo, mailSvc)
+		repo.EXPECT().MarkEmailVerified(gomock.Any(), int64(123), "123@qq.com").Return(nil)
+
+		assert.NoError(t, svc.Verify(context.Background(), token))
+	})
+
+	t.Run("已经换了邮箱", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, repo, mailSvc := newSvc(ctrl, time.Hour)
+		token := sendAndGetToken(t, svc, repo, mailSvc)
+		repo.EXPECT().MarkEmailVerified(gomock.Any(), int64(123), "123@qq.com").Return(repository.ErrUserNotFound)
+
+		assert.ErrorIs(t, svc.Verify(context.Background(), token), service.ErrEmailVerifyTokenInvalid)
+	})
+
+	t.Run("链接过期", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, repo, mailSvc := newSvc(ctrl, -time.Minute)
+		token := sendAndGetToken(t, svc, repo, mailSvc)
+
+		assert.ErrorIs(t, svc.Verify(context.Background(), token), service.ErrEmailVerifyTokenInvalid)
+	})
+
+	t.Run("token 被篡改", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, repo, mailSvc := newSvc(ctrl, time.Hour)
+		token := sendAndGetToken(t, svc, repo, mailSvc)
+
+		assert.ErrorIs(t, svc.Verify(context.Background(), token+"x"), service.ErrEmailVerifyTokenInvalid)
+	})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/captcha"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+var (
+	// ErrLoginLocked 实际返回的错误中 Msg 带有需要等待的时间
+	ErrLoginLocked     = errs.New(errs.UserLoginLocked, "登录失败次数过多，请稍后再试")
+	ErrCaptchaRequired = errs.New(errs.UserCaptchaRequired, "请先完成人机验证")
+	ErrCaptchaInvalid  = errs.New(errs.UserCaptchaRequired, "人机验证没有通过，请重试")
+)
+
+// LoginGuard 防止暴力破解邮箱密码登录，按邮箱和 IP 统计失败次数
+//
+//go:generate go run go.uber.org/mock/mockgen -source=login_guard.go -package=svcmocks -destination=mocks/login_guard.mock.go LoginGuard
+type LoginGuard interface {
+	// Check 登录前调用，被锁定或者需要人机验证时返回错误
+	Check(ctx context.Context, email, ip, captchaToken string) error
+	// OnFailure 密码错误时调用，失败次数越多返回得越慢
+	OnFailure(ctx context.Context, email, ip string) error
+	// OnSuccess 登录成功后调用，清空邮箱的失败次数
+	OnSuccess(ctx context.Context, email string) error
+}
+
+// LoginGuardOptions 锁定的阈值在 cache 中配置
+type LoginGuardOptions struct {
+	// 失败这么多次之后需要人机验证，0 表示不需要
+	CaptchaThreshold int
+	// 第 n 次失败延迟 BaseDelay * 2^(n-1) 返回，最多延迟 MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+type loginGuard struct {
+	repo repository.LoginAttemptRepository
+	// CaptchaThreshold 为 0 时可以为 nil
+	verifier captcha.Verifier
+	opts     LoginGuardOptions
+}
+
+func NewLoginGuard(repo repository.LoginAttemptRepository, verifier captcha.Verifier,
+	opts LoginGuardOptions) LoginGuard {
+	return &loginGuard{
+		repo:     repo,
+		verifier: verifier,
+		opts:     opts,
+	}
+}
+
+func (g *loginGuard) Check(ctx context.Context, email, ip, captchaToken string) error {
+	attempts, err := g.repo.Get(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	if ttl := max(attempts.EmailLockTTL, attempts.IPLockTTL); ttl > 0 {
+		minutes := int(math.Ceil(ttl.Minutes()))
+		return errs.New(errs.UserLoginLocked, fmt.Sprintf("登录失败次数过多，请%d分钟后再试", minutes))
+	}
+	if g.opts.CaptchaThreshold <= 0 || max(attempts.EmailFailures, attempts.IPFailures) < g.opts.CaptchaThreshold {
+		return nil
+	}
+	if captchaToken == "" {
+		return ErrCaptchaRequired
+	}
+	ok, err := g.verifier.Verify(ctx, captchaToken, ip)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCaptchaInvalid
+	}
+	return nil
+}
+
+func (g *loginGuard) OnFailure(ctx context.Context, email, ip string) error {
+	attempts, err := g.repo.RecordFailure(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	delay := g.delay(max(attempts.EmailFailures, attempts.IPFailures))
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (g *loginGuard) OnSuccess(ctx context.Context, email string) error {
+	return g.repo.Reset(ctx, email)
+}
+
+func (g *loginGuard) delay(failures int) time.Duration {
+	if failures <= 0 || g.opts.BaseDelay <= 0 {
+		return 0
+	}
+	// 避免移位溢出
+	d := g.opts.BaseDelay << min(failures-1, 20)
+	return min(d, g.opts.MaxDelay)
+}
+
+// nopLoginGuard 关闭登录保护时使用
+type nopLoginGuard struct{}
+
+func NewNopLoginGuard() LoginGuard {
+	return nopLoginGuard{}
+}
+
+func (nopLoginGuard) Check(ctx context.Context, email, ip, captchaToken string) error {
+	return nil
+}
+
+func (nopLoginGuard) OnFailure(ctx context.Context, email, ip string) error {
+	return nil
+}
+
+func (nopLoginGuard) OnSuccess(ctx context.Context, email string) error {
+	return nil
+}
@@ -0,0 +1,122 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	repomocks "github.com/newton-miku/webook/webook-be/internal/repository/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	captchamocks "github.com/newton-miku/webook/webook-be/internal/service/captcha/mocks"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoginGuard_Check(t *testing.T) {
+	const (
+		email = "123@qq.com"
+		ip    = "127.0.0.1"
+	)
+	testCases := []struct {
+		name     string
+		mock     func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier)
+		captcha  string
+		wantCode errs.Code
+		wantMsg  string
+	}{
+		{
+			name: "没有失败记录",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).Return(domain.LoginAttempts{}, nil)
+			},
+		},
+		{
+			name: "邮箱被锁定，提示剩余分钟数",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).
+					Return(domain.LoginAttempts{EmailLockTTL: 4*time.Minute + time.Second}, nil)
+			},
+			wantCode: errs.UserLoginLocked,
+			wantMsg:  "登录失败次数过多，请5分钟后再试",
+		},
+		{
+			name: "IP 被锁定",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).
+					Return(domain.LoginAttempts{IPLockTTL: 10 * time.Second}, nil)
+			},
+			wantCode: errs.UserLoginLocked,
+			wantMsg:  "登录失败次数过多，请1分钟后再试",
+		},
+		{
+			name: "失败次数达到阈值，需要人机验证",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).Return(domain.LoginAttempts{EmailFailures: 3}, nil)
+			},
+			wantCode: errs.UserCaptchaRequired,
+			wantMsg:  "请先完成人机验证",
+		},
+		{
+			name: "人机验证没有通过",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).Return(domain.LoginAttempts{IPFailures: 5}, nil)
+				verifier.EXPECT().Verify(gomock.Any(), "token", ip).Return(false, nil)
+			},
+			captcha:  "token",
+			wantCode: errs.UserCaptchaRequired,
+			wantMsg:  "人机验证没有通过，请重试",
+		},
+		{
+			name: "人机验证通过",
+			mock: func(repo *repomocks.MockLoginAttemptRepository, verifier *captchamocks.MockVerifier) {
+				repo.EXPECT().Get(gomock.Any(), email, ip).Return(domain.LoginAttempts{EmailFailures: 3}, nil)
+				verifier.EXPECT().Verify(gomock.Any(), "token", ip).Return(true, nil)
+			},
+			captcha: "token",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+			verifier := captchamocks.NewMockVerifier(ctrl)
+			tc.mock(repo, verifier)
+			guard := service.NewLoginGuard(repo, verifier, service.LoginGuardOptions{CaptchaThreshold: 3})
+
+			err := guard.Check(context.Background(), email, ip, tc.captcha)
+			if tc.wantCode == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			e, ok := errs.From(err)
+			assert.True(t, ok)
+			assert.Equal(t, tc.wantCode, e.Code)
+			assert.Equal(t, tc.wantMsg, e.Msg)
+		})
+	}
+}
+
+func TestLoginGuard_OnFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := repomocks.NewMockLoginAttemptRepository(ctrl)
+	guard := service.NewLoginGuard(repo, nil, service.LoginGuardOptions{
+		BaseDelay: 10 * time.Millisecond,
+		MaxDelay:  30 * time.Millisecond,
+	})
+
+	// 第 2 次失败延迟 20ms
+	repo.EXPECT().RecordFailure(gomock.Any(), "123@qq.com", "127.0.0.1").
+		Return(domain.LoginAttempts{EmailFailures: 2, IPFailures: 1}, nil)
+	start := time.Now()
+	assert.NoError(t, guard.OnFailure(context.Background(), "123@qq.com", "127.0.0.1"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// 超过上限后按 MaxDelay 延迟，请求取消时提前返回
+	repo.EXPECT().RecordFailure(gomock.Any(), "123@qq.com", "127.0.0.1").
+		Return(domain.LoginAttempts{EmailFailures: 10}, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, guard.OnFailure(ctx, "123@qq.com", "127.0.0.1"), context.DeadlineExceeded)
+}
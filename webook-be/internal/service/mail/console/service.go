@@ -0,0 +1,19 @@
+package console
+
+import (
+	"context"
+	"log"
+)
+
+// Service 本地开发用的邮件实现，只把邮件内容打印出来
+type Service struct {
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+func (s *Service) Send(ctx context.Context, to, subject, body string) error {
+	log.Printf("发送邮件 收件人: %s 主题: %s\n%s\n", to, subject, body)
+	return nil
+}
@@ -0,0 +1,41 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Service 把邮件写到目录中，每封邮件一个 .eml 文件，可以直接用邮件客户端打开
+// 用于本地开发和测试环境查看发出的邮件
+type Service struct {
+	dir  string
+	from string
+}
+
+func NewService(dir, from string) (*Service, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Service{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (s *Service) Send(ctx context.Context, to, subject, body string) error {
+	now := time.Now()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", s.from)
+	fmt.Fprintf(&sb, "To: %s\r\n", to)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&sb, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	sb.WriteString(body)
+	// 文件名带上收件人，方便按收件人查找
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), strings.NewReplacer("/", "_", "\\", "_").Replace(to))
+	return os.WriteFile(filepath.Join(s.dir, name), []byte(sb.String()), 0o644)
+}
@@ -0,0 +1,10 @@
+package mail
+
+import "context"
+
+// Service 发送邮件的抽象，body 为纯文本
+//
+//go:generate go run go.uber.org/mock/mockgen -source=types.go -package=mailmocks -destination=mocks/types.mock.go Service
+type Service interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+)
+
+var ErrMailSendTooMany = errs.New(errs.UserMailSendTooMany, "邮件发送太频繁，请稍后再试")
+
+// limitMail 按收件人限制邮件，biz 区分不同用途的邮件
+// 要在查询用户之前调用，否则可以通过是否被限流判断邮箱有没有注册
+// l 为 nil 时不限流
+func limitMail(ctx context.Context, l limiter.Limiter, biz, email string) error {
+	if l == nil {
+		return nil
+	}
+	limited, err := l.Limit(ctx, fmt.Sprintf("mail-limiter:%s:%s", biz, email))
+	if err != nil {
+		return err
+	}
+	if limited {
+		return ErrMailSendTooMany
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/totp"
+)
+
+var ErrMFACodeInvalid = errs.New(errs.UserMFACodeInvalid, "验证码有误")
+
+const (
+	// 恢复码的个数
+	mfaRecoveryCodeCnt = 10
+	// 允许前后一个时间步的时钟误差
+	mfaSkew = 1
+)
+
+//go:generate go run go.uber.org/mock/mockgen -source=mfa.go -package=svcmocks -destination=mocks/mfa.mock.go MFAService
+type MFAService interface {
+	// Enroll 生成新的密钥，验证第一个验证码之前不会生效
+	Enroll(ctx context.Context, uid int64) (MFAEnrollment, error)
+	// Activate 验证第一个验证码，开启两步验证，返回恢复码
+	// 恢复码只在这里返回一次
+	Activate(ctx context.Context, uid int64, code string) ([]string, error)
+	// Disable 关闭两步验证，需要验证码或者恢复码
+	// 错误次数和 CompleteLogin 一起统计，次数过多时锁定
+	Disable(ctx context.Context, uid int64, code string) error
+	// BeginLogin 第一步验证通过后调用，没有开启两步验证时返回空字符串
+	// 否则返回完成登录使用的 token
+	BeginLogin(ctx context.Context, uid int64, method string) (string, error)
+	// CompleteLogin 校验验证码或者恢复码，成功后 token 失效
+	// 同一个用户错误次数过多时锁定一段时间，锁定期间验证码正确也无法登录
+	CompleteLogin(ctx context.Context, token, code string) (domain.MFALoginPending, error)
+}
+
+// MFAEnrollment 验证器应用扫码或者手动输入使用
+type MFAEnrollment struct {
+	// base32 编码的密钥
+	Secret string
+	// otpauth:// 地址，前端生成二维码
+	URI string
+}
+
+type MFAOptions struct {
+	// 验证器应用中显示的服务名称
+	Issuer string
+	// 加密数据库中的密钥，AES-256，32 个字节
+	EncryptKey []byte
+	// 当前时间，测试时可以使用固定的时钟，为空时使用 time.Now
+	Now func() time.Time
+}
+
+type mfaService struct {
+	repo     repository.MFARepository
+	userRepo repository.UserRepository
+	issuer   string
+	aead     cipher.AEAD
+	now      func() time.Time
+}
+
+func NewMFAService(repo repository.MFARepository, userRepo repository.UserRepository,
+	opts MFAOptions) (MFAService, error) {
+	block, err := aes.NewCipher(opts.EncryptKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &mfaService{
+		repo:     repo,
+		userRepo: userRepo,
+		issuer:   opts.Issuer,
+		aead:     aead,
+		now:      now,
+	}, nil
+}
+
+func (svc *mfaService) Enroll(ctx context.Context, uid int64) (MFAEnrollment, error) {
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+	encrypted, err := svc.encrypt(secret)
+	if err != nil {
+		return MFAEnrollment{}, err
+	}
+	if err = svc.repo.SavePending(ctx, uid, encrypted); err != nil {
+		return MFAEnrollment{}, err
+	}
+	return MFAEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(svc.issuer, svc.accountName(u), secret),
+	}, nil
+}
+
+// accountName 验证器应用中显示的账号
+func (svc *mfaService) accountName(u domain.User) string {
+	switch {
+	case u.Email != "":
+		return u.Email
+	case u.Phone != "":
+		return u.Phone
+	default:
+		return strconv.FormatInt(u.Id, 10)
+	}
+}
+
+func (svc *mfaService) Activate(ctx context.Context, uid int64, code string) ([]string, error) {
+	m, err := svc.repo.FindByUID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if m.Enabled {
+		return nil, repository.ErrMFAAlreadyEnabled
+	}
+	secret, err := svc.decrypt(m.Secret)
+	if err != nil {
+		return nil, err
+	}
+	// 激活时只接受验证码，确认用户的验证器应用配置正确
+	step, ok := totp.Validate(secret, code, svc.now(), mfaSkew)
+	if !ok {
+		return nil, ErrMFACodeInvalid
+	}
+	codes := make([]string, 0, mfaRecoveryCodeCnt)
+	hashes := make([]string, 0, mfaRecoveryCodeCnt)
+	for range mfaRecoveryCodeCnt {
+		c := newRecoveryCode()
+		codes = append(codes, c)
+		hashes = append(hashes, hashRecoveryCode(c))
+	}
+	if err = svc.repo.Enable(ctx, uid, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (svc *mfaService) Disable(ctx context.Context, uid int64, code string) error {
+	// 和登录共用错误次数，拿到登录态的人也不能穷举验证码来关闭两步验证
+	ttl, err := svc.repo.LoginLockTTL(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return mfaLockedErr(ttl)
+	}
+	m, err := svc.repo.FindByUID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if !m.Enabled {
+		return repository.ErrMFANotEnabled
+	}
+	err = svc.verify(ctx, m, code)
+	if errors.Is(err, ErrMFACodeInvalid) {
+		ttl, ierr := svc.repo.RecordLoginFailure(ctx, uid)
+		if ierr != nil {
+			return ierr
+		}
+		if ttl > 0 {
+			return mfaLockedErr(ttl)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = svc.repo.Delete(ctx, uid); err != nil {
+		return err
+	}
+	if err = svc.repo.ResetLoginFailures(ctx, uid); err != nil {
+		slog.Error("清空两步验证失败次数失败", slog.Int64("uid", uid), slog.Any("err", err))
+	}
+	return nil
+}
+
+func (svc *mfaService) BeginLogin(ctx context.Context, uid int64, method string) (string, error) {
+	m, err := svc.repo.FindByUID(ctx, uid)
+	if errors.Is(err, repository.ErrMFANotEnabled) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// 只获取过密钥、没有激活的不需要两步验证
+	if !m.Enabled {
+		return "", nil
+	}
+	token := rand.Text()
+	err = svc.repo.SetLoginPending(ctx, token, domain.MFALoginPending{
+		UID:    uid,
+		Method: method,
+	})
+	return token, err
+}
+
+func (svc *mfaService) CompleteLogin(ctx context.Context, token, code string) (domain.MFALoginPending, error) {
+	p, err := svc.repo.GetLoginPending(ctx, token)
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	// 错误次数按用户统计，重新登录拿到新的 token 也不能继续穷举验证码
+	ttl, err := svc.repo.LoginLockTTL(ctx, p.UID)
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	if ttl > 0 {
+		return domain.MFALoginPending{}, mfaLockedErr(ttl)
+	}
+	m, err := svc.repo.FindByUID(ctx, p.UID)
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	err = svc.verify(ctx, m, code)
+	if errors.Is(err, ErrMFACodeInvalid) {
+		ttl, ierr := svc.repo.RecordLoginFailure(ctx, p.UID)
+		if ierr != nil {
+			return domain.MFALoginPending{}, ierr
+		}
+		if ttl > 0 {
+			// 锁定后这次登录也作废，解锁后需要重新登录
+			if _, ierr = svc.repo.DeleteLoginPending(ctx, token); ierr != nil {
+				return domain.MFALoginPending{}, ierr
+			}
+			return domain.MFALoginPending{}, mfaLockedErr(ttl)
+		}
+		return domain.MFALoginPending{}, err
+	}
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	// 同一个 token 只能完成一次登录
+	ok, err := svc.repo.DeleteLoginPending(ctx, token)
+	if err != nil {
+		return domain.MFALoginPending{}, err
+	}
+	if !ok {
+		return domain.MFALoginPending{}, repository.ErrMFATokenInvalid
+	}
+	// token 已经用掉了，清空失败次数出错也要让用户登录成功
+	if err = svc.repo.ResetLoginFailures(ctx, p.UID); err != nil {
+		slog.Error("清空两步验证失败次数失败", slog.Int64("uid", p.UID), slog.Any("err", err))
+	}
+	return p, nil
+}
+
+func mfaLockedErr(ttl time.Duration) error {
+	minutes := int(math.Ceil(ttl.Minutes()))
+	return errs.New(errs.UserLoginLocked, fmt.Sprintf("两步验证失败次数过多，请%d分钟后再试", minutes))
+}
+
+// verify 校验验证码或者恢复码，6 位数字按验证码处理，其它按恢复码处理
+// 验证码和恢复码都只能使用一次
+func (svc *mfaService) verify(ctx context.Context, m domain.MFA, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) != totp.Digits {
+		ok, err := svc.repo.UseRecoveryCode(ctx, m.UID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrMFACodeInvalid
+		}
+		return nil
+	}
+	secret, err := svc.decrypt(m.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, svc.now(), mfaSkew)
+	if !ok || step <= m.LastStep {
+		return ErrMFACodeInvalid
+	}
+	// 并发使用同一个验证码时只有一个会成功
+	ok, err = svc.repo.UpdateLastStep(ctx, m.UID, step)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMFACodeInvalid
+	}
+	return nil
+}
+
+// encrypt 使用 AES-GCM 加密密钥，结果为 base64(nonce + 密文)
+func (svc *mfaService) encrypt(secret []byte) (string, error) {
+	nonce := make([]byte, svc.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := svc.aead.Seal(nonce, nonce, secret, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (svc *mfaService) decrypt(encrypted string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	size := svc.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("两步验证密钥格式错误")
+	}
+	return svc.aead.Open(nil, sealed[:size], sealed[size:], nil)
+}
+
+// newRecoveryCode 生成形如 abcde-fghij 的恢复码
+func newRecoveryCode() string {
+	text := strings.ToLower(rand.Text())
+	return text[:5] + "-" + text[5:10]
+}
+
+// hashRecoveryCode 忽略大小写和连字符，用户手动输入时不用太在意格式
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,233 @@
+package service_test
+
+import (
+	"context"
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	repomocks "github.com/newton-miku/webook/webook-be/internal/repository/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// 固定的时钟，验证码不会因为跨越时间步而变化
+var testMFANow = time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+
+func newMFAService(t *testing.T, ctrl *gomock.Controller) (service.MFAService,
+	*repomocks.MockMFARepository, *repomocks.MockUserRepository) {
+	repo := repomocks.NewMockMFARepository(ctrl)
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	svc, err := service.NewMFAService(repo, userRepo, service.MFAOptions{
+		Issuer:     "webook",
+		EncryptKey: []byte("0123456789abcdef0123456789abcdef"),
+		Now:        func() time.Time { return testMFANow },
+	})
+	require.NoError(t, err)
+	return svc, repo, userRepo
+}
+
+// enroll 走一遍获取密钥的流程，返回明文密钥和加密后保存的密钥
+func enroll(t *testing.T, svc service.MFAService, repo *repomocks.MockMFARepository,
+	userRepo *repomocks.MockUserRepository) ([]byte, string) {
+	userRepo.EXPECT().FindByID(gomock.Any(), int64(123)).Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+	var encrypted string
+	repo.EXPECT().SavePending(gomock.Any(), int64(123), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, uid int64, secret string) error {
+			encrypted = secret
+			return nil
+		})
+	e, err := svc.Enroll(context.Background(), 123)
+	require.NoError(t, err)
+	assert.Contains(t, e.URI, "otpauth://totp/webook:123@qq.com?")
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	require.NoError(t, err)
+	// 数据库中不能是明文
+	assert.NotContains(t, encrypted, e.Secret)
+	return secret, encrypted
+}
+
+func TestMFAService_Activate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc, repo, userRepo := newMFAService(t, ctrl)
+	secret, encrypted := enroll(t, svc, repo, userRepo)
+
+	repo.EXPECT().FindByUID(gomock.Any(), int64(123)).Return(domain.MFA{UID: 123, Secret: encrypted}, nil).Times(2)
+	_, err := svc.Activate(context.Background(), 123, "000000")
+	assert.Equal(t, service.ErrMFACodeInvalid, err)
+
+	var hashes []string
+	repo.EXPECT().Enable(gomock.Any(), int64(123), totp.Step(testMFANow), gomock.Len(10)).
+		DoAndReturn(func(ctx context.Context, uid int64, step int64, h []string) error {
+			hashes = h
+			return nil
+		})
+	codes, err := svc.Activate(context.Background(), 123, totp.Code(secret, testMFANow))
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	assert.NotContains(t, hashes, codes[0])
+}
+
+func TestMFAService_CompleteLogin(t *testing.T) {
+	const token = "token"
+	pending := domain.MFALoginPending{UID: 123, Method: "email"}
+	ctrl := gomock.NewController(t)
+	svc, repo, userRepo := newMFAService(t, ctrl)
+	secret, encrypted := enroll(t, svc, repo, userRepo)
+	step := totp.Step(testMFANow)
+	code := totp.Code(secret, testMFANow)
+
+	testCases := []struct {
+		name string
+		// 用户的两步验证还要锁定多久
+		locked  time.Duration
+		mock    func()
+		code    string
+		wantErr error
+	}{
+		{
+			name: "验证码正确",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true, LastStep: step - 2}, nil)
+				repo.EXPECT().UpdateLastStep(gomock.Any(), int64(123), step).Return(true, nil)
+				repo.EXPECT().DeleteLoginPending(gomock.Any(), token).Return(true, nil)
+				repo.EXPECT().ResetLoginFailures(gomock.Any(), int64(123)).Return(nil)
+			},
+			code: code,
+		},
+		{
+			name: "上一个时间步的验证码也可以",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true, LastStep: step - 2}, nil)
+				repo.EXPECT().UpdateLastStep(gomock.Any(), int64(123), step-1).Return(true, nil)
+				repo.EXPECT().DeleteLoginPending(gomock.Any(), token).Return(true, nil)
+				repo.EXPECT().ResetLoginFailures(gomock.Any(), int64(123)).Return(nil)
+			},
+			code: totp.Code(secret, testMFANow.Add(-totp.Period)),
+		},
+		{
+			name: "验证码已经用过",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true, LastStep: step}, nil)
+				repo.EXPECT().RecordLoginFailure(gomock.Any(), int64(123)).Return(time.Duration(0), nil)
+			},
+			code:    code,
+			wantErr: service.ErrMFACodeInvalid,
+		},
+		{
+			name: "错误次数过多",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true}, nil)
+				repo.EXPECT().RecordLoginFailure(gomock.Any(), int64(123)).Return(15*time.Minute, nil)
+				repo.EXPECT().DeleteLoginPending(gomock.Any(), token).Return(true, nil)
+			},
+			code:    "000000",
+			wantErr: errs.New(errs.UserLoginLocked, "两步验证失败次数过多，请15分钟后再试"),
+		},
+		{
+			name:    "换了 token 也还在锁定中",
+			locked:  90 * time.Second,
+			mock:    func() {},
+			code:    code,
+			wantErr: errs.New(errs.UserLoginLocked, "两步验证失败次数过多，请2分钟后再试"),
+		},
+		{
+			name: "恢复码",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true}, nil)
+				repo.EXPECT().UseRecoveryCode(gomock.Any(), int64(123), gomock.Any()).Return(true, nil)
+				repo.EXPECT().DeleteLoginPending(gomock.Any(), token).Return(true, nil)
+				repo.EXPECT().ResetLoginFailures(gomock.Any(), int64(123)).Return(nil)
+			},
+			code: "abcde-fghij",
+		},
+		{
+			name: "并发完成登录",
+			mock: func() {
+				repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+					Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true}, nil)
+				repo.EXPECT().UpdateLastStep(gomock.Any(), int64(123), step).Return(true, nil)
+				repo.EXPECT().DeleteLoginPending(gomock.Any(), token).Return(false, nil)
+			},
+			code:    code,
+			wantErr: repository.ErrMFATokenInvalid,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo.EXPECT().GetLoginPending(gomock.Any(), token).Return(pending, nil)
+			repo.EXPECT().LoginLockTTL(gomock.Any(), int64(123)).Return(tc.locked, nil)
+			tc.mock()
+			p, err := svc.CompleteLogin(context.Background(), token, tc.code)
+			assert.Equal(t, tc.wantErr, err)
+			if tc.wantErr == nil {
+				assert.Equal(t, pending, p)
+			}
+		})
+	}
+}
+
+func TestMFAService_Disable(t *testing.T) {
+	const maxFailures = 5
+	ctrl := gomock.NewController(t)
+	svc, repo, userRepo := newMFAService(t, ctrl)
+	secret, encrypted := enroll(t, svc, repo, userRepo)
+	code := totp.Code(secret, testMFANow)
+
+	// 模拟缓存中的失败次数，达到 maxFailures 次后锁定
+	var failures int
+	lockTTL := func() time.Duration {
+		if failures >= maxFailures {
+			return 15 * time.Minute
+		}
+		return 0
+	}
+	repo.EXPECT().LoginLockTTL(gomock.Any(), int64(123)).
+		DoAndReturn(func(ctx context.Context, uid int64) (time.Duration, error) {
+			return lockTTL(), nil
+		}).AnyTimes()
+	repo.EXPECT().RecordLoginFailure(gomock.Any(), int64(123)).
+		DoAndReturn(func(ctx context.Context, uid int64) (time.Duration, error) {
+			failures++
+			return lockTTL(), nil
+		}).Times(maxFailures)
+	repo.EXPECT().FindByUID(gomock.Any(), int64(123)).
+		Return(domain.MFA{UID: 123, Secret: encrypted, Enabled: true}, nil).Times(maxFailures)
+	repo.EXPECT().UseRecoveryCode(gomock.Any(), int64(123), gomock.Any()).Return(false, nil).Times(maxFailures)
+
+	locked := errs.New(errs.UserLoginLocked, "两步验证失败次数过多，请15分钟后再试")
+	for i := 1; i < maxFailures; i++ {
+		assert.Equal(t, service.ErrMFACodeInvalid, svc.Disable(context.Background(), 123, "abcde-fghij"))
+	}
+	assert.Equal(t, locked, svc.Disable(context.Background(), 123, "abcde-fghij"))
+	// 锁定之后正确的验证码也不行，不会再去校验
+	assert.Equal(t, locked, svc.Disable(context.Background(), 123, code))
+}
+
+func TestMFAService_BeginLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc, repo, _ := newMFAService(t, ctrl)
+
+	repo.EXPECT().FindByUID(gomock.Any(), int64(123)).Return(domain.MFA{}, repository.ErrMFANotEnabled)
+	token, err := svc.BeginLogin(context.Background(), 123, "email")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	repo.EXPECT().FindByUID(gomock.Any(), int64(123)).Return(domain.MFA{UID: 123, Enabled: true}, nil)
+	repo.EXPECT().SetLoginPending(gomock.Any(), gomock.Any(), domain.MFALoginPending{UID: 123, Method: "email"}).Return(nil)
+	token, err = svc.BeginLogin(context.Background(), 123, "email")
+	require.NoError(t, err)
+	assert.NotEmpty(t, token)
+}
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: email_verify.go
+//
+// Generated by this command:
+//
+//	mockgen -source=email_verify.go -package=svcmocks -destination=mocks/email_verify.mock.go EmailVerifyService
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/newton-miku/webook/webook-be/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailVerifyService is a mock of EmailVerifyService interface.
+type MockEmailVerifyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerifyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailVerifyServiceMockRecorder is the mock recorder for MockEmailVerifyService.
+type MockEmailVerifyServiceMockRecorder struct {
+	mock *MockEmailVerifyService
+}
+
+// NewMockEmailVerifyService creates a new mock instance.
+func NewMockEmailVerifyService(ctrl *gomock.Controller) *MockEmailVerifyService {
+	mock := &MockEmailVerifyService{ctrl: ctrl}
+	mock.recorder = &MockEmailVerifyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerifyService) EXPECT() *MockEmailVerifyServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckLogin mocks base method.
+func (m *MockEmailVerifyService) CheckLogin(u domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckLogin", u)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckLogin indicates an expected call of CheckLogin.
+func (mr *MockEmailVerifyServiceMockRecorder) CheckLogin(u any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLogin", reflect.TypeOf((*MockEmailVerifyService)(nil).CheckLogin), u)
+}
+
+// CheckPost mocks base method.
+func (m *MockEmailVerifyService) CheckPost(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckPost", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckPost indicates an expected call of CheckPost.
+func (mr *MockEmailVerifyServiceMockRecorder) CheckPost(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckPost", reflect.TypeOf((*MockEmailVerifyService)(nil).CheckPost), ctx, uid)
+}
+
+// SendVerifyMail mocks base method.
+func (m *MockEmailVerifyService) SendVerifyMail(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerifyMail", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerifyMail indicates an expected call of SendVerifyMail.
+func (mr *MockEmailVerifyServiceMockRecorder) SendVerifyMail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerifyMail", reflect.TypeOf((*MockEmailVerifyService)(nil).SendVerifyMail), ctx, email)
+}
+
+// Verify mocks base method.
+func (m *MockEmailVerifyService) Verify(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockEmailVerifyServiceMockRecorder) Verify(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockEmailVerifyService)(nil).Verify), ctx, token)
+}
@@ -0,0 +1,83 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: login_guard.go
+//
+// Generated by this command:
+//
+//	mockgen -source=login_guard.go -package=svcmocks -destination=mocks/login_guard.mock.go LoginGuard
+//
+
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginGuard is a mock of LoginGuard interface.
+type MockLoginGuard struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginGuardMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginGuardMockRecorder is the mock recorder for MockLoginGuard.
+type MockLoginGuardMockRecorder struct {
+	mock *MockLoginGuard
+}
+
+// NewMockLoginGuard creates a new mock instance.
+func NewMockLoginGuard(ctrl *gomock.Controller) *MockLoginGuard {
+	mock := &MockLoginGuard{ctrl: ctrl}
+	mock.recorder = &MockLoginGuardMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginGuard) EXPECT() *MockLoginGuardMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockLoginGuard) Check(ctx context.Context, email, ip, captchaToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx, email, ip, captchaToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockLoginGuardMockRecorder) Check(ctx, email, ip, captchaToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockLoginGuard)(nil).Check), ctx, email, ip, captchaToken)
+}
+
+// OnFailure mocks base method.
+func (m *MockLoginGuard) OnFailure(ctx context.Context, email, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnFailure", ctx, email, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnFailure indicates an expected call of OnFailure.
+func (mr *MockLoginGuardMockRecorder) OnFailure(ctx, email, ip any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnFailure", reflect.TypeOf((*MockLoginGuard)(nil).OnFailure), ctx, email, ip)
+}
+
+// OnSuccess mocks base method.
+func (m *MockLoginGuard) OnSuccess(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnSuccess", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnSuccess indicates an expected call of OnSuccess.
+func (mr *MockLoginGuardMockRecorder) OnSuccess(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnSuccess", reflect.TypeOf((*MockLoginGuard)(nil).OnSuccess), ctx, email)
+}
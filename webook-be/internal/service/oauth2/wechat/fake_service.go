@@ -0,0 +1,32 @@
+package wechat
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+)
+
+// fakeService 不访问微信的实现，用于测试和本地开发
+// AuthURL 直接跳回回调地址，code 会被当作 openid 使用
+type fakeService struct {
+	redirectURL string
+}
+
+func NewFakeService(redirectURL string) Service {
+	return &fakeService{
+		redirectURL: redirectURL,
+	}
+}
+
+func (s *fakeService) AuthURL(ctx context.Context, state string) (string, error) {
+	return fmt.Sprintf("%s?code=%s&state=%s", s.redirectURL,
+		url.QueryEscape("fake-code"), url.QueryEscape(state)), nil
+}
+
+func (s *fakeService) VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error) {
+	return domain.WechatInfo{
+		OpenID: "fake_openid_" + code,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+)
+
+const authURLPattern = "https://open.weixin.qq.com/connect/qrconnect?appid=%s&redirect_uri=%s&response_type=code&scope=snsapi_login&state=%s#wechat_redirect"
+
+const accessTokenURLPattern = "https://api.weixin.qq.com/sns/oauth2/access_token?appid=%s&secret=%s&code=%s&grant_type=authorization_code"
+
+type Service interface {
+	// AuthURL 构造微信扫码登录的跳转地址
+	AuthURL(ctx context.Context, state string) (string, error)
+	// VerifyCode 用回调中的 code 换取用户的微信身份
+	VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error)
+}
+
+type service struct {
+	appID       string
+	appSecret   string
+	redirectURL string
+	client      *http.Client
+}
+
+func NewService(appID, appSecret, redirectURL string) Service {
+	return &service{
+		appID:       appID,
+		appSecret:   appSecret,
+		redirectURL: url.QueryEscape(redirectURL),
+		client:      http.DefaultClient,
+	}
+}
+
+func (s *service) AuthURL(ctx context.Context, state string) (string, error) {
+	return fmt.Sprintf(authURLPattern, s.appID, s.redirectURL, state), nil
+}
+
+func (s *service) VerifyCode(ctx context.Context, code string) (domain.WechatInfo, error) {
+	target := fmt.Sprintf(accessTokenURLPattern, s.appID, s.appSecret, url.QueryEscape(code))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return domain.WechatInfo{}, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return domain.WechatInfo{}, err
+	}
+	defer resp.Body.Close()
+	var res Result
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return domain.WechatInfo{}, err
+	}
+	if res.ErrCode != 0 {
+		return domain.WechatInfo{}, fmt.Errorf("微信返回错误 errcode: %d, errmsg: %s", res.ErrCode, res.ErrMsg)
+	}
+	return domain.WechatInfo{
+		OpenID:  res.OpenID,
+		UnionID: res.UnionID,
+	}, nil
+}
+
+// Result 微信 access_token 接口的返回值
+type Result struct {
+	ErrCode int64  `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+
+	OpenID  string `json:"openid"`
+	Scope   string `json:"scope"`
+	UnionID string `json:"unionid"`
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+// bcryptMaxBytes bcrypt 只使用密码的前 72 个字节，超出部分会被忽略
+const bcryptMaxBytes = 72
+
+// ErrWeakPassword 密码不符合密码策略，实际返回的错误中 Msg 是具体原因
+var ErrWeakPassword = errs.New(errs.UserWeakPassword, "密码强度不够")
+
+// commonPasswords 常见密码和已泄露的密码，每行一个，全部为小写
+//
+//go:embed data/common_passwords.txt
+var commonPasswords string
+
+var loadCommonPasswords = sync.OnceValue(func() map[string]struct{} {
+	res := make(map[string]struct{}, 1024)
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswords))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res[strings.ToLower(line)] = struct{}{}
+	}
+	return res
+})
+
+// PasswordPolicy 密码策略，注册、修改密码、重置密码都要用它校验新密码
+type PasswordPolicy struct {
+	// 最少的字符数
+	MinLength int
+	// 最多的字节数，不能超过 72
+	MaxBytes int
+	// 必须包含的字符种类
+	RequireLetter bool
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// 不能包含邮箱 @ 前面的部分
+	DisallowEmail bool
+	// 不能是常见密码或者已泄露的密码
+	CheckCommon bool
+}
+
+func NewPasswordPolicy(p PasswordPolicy) *PasswordPolicy {
+	if p.MaxBytes <= 0 || p.MaxBytes > bcryptMaxBytes {
+		p.MaxBytes = bcryptMaxBytes
+	}
+	return &p
+}
+
+// Check 校验密码是否符合策略，一次返回所有不符合的地方
+// email 为空时不检查是否包含邮箱
+func (p *PasswordPolicy) Check(password, email string) error {
+	var reasons []string
+	if n := len([]rune(password)); n < p.MinLength {
+		reasons = append(reasons, fmt.Sprintf("长度不能少于%d位", p.MinLength))
+	}
+	if len(password) > p.MaxBytes {
+		reasons = append(reasons, fmt.Sprintf("长度不能超过%d个字节", p.MaxBytes))
+	}
+	var letter, upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			letter, upper = true, true
+		case unicode.IsLower(r):
+			letter, lower = true, true
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	for _, c := range []struct {
+		required, ok bool
+		reason       string
+	}{
+		{p.RequireLetter, letter, "必须包含字母"},
+		{p.RequireUpper, upper, "必须包含大写字母"},
+		{p.RequireLower, lower, "必须包含小写字母"},
+		{p.RequireDigit, digit, "必须包含数字"},
+		{p.RequireSymbol, symbol, "必须包含特殊字符"},
+	} {
+		if c.required && !c.ok {
+			reasons = append(reasons, c.reason)
+		}
+	}
+	lowered := strings.ToLower(password)
+	if p.DisallowEmail {
+		// 太短的用户名很容易误伤，比如 a@qq.com
+		if local, _, _ := strings.Cut(strings.ToLower(email), "@"); len(local) >= 3 &&
+			strings.Contains(lowered, local) {
+			reasons = append(reasons, "不能包含邮箱用户名")
+		}
+	}
+	if p.CheckCommon {
+		if _, ok := loadCommonPasswords()[lowered]; ok {
+			reasons = append(reasons, "过于常见或者已经泄露，请换一个")
+		}
+	}
+	if len(reasons) > 0 {
+		return errs.New(errs.UserWeakPassword, "密码"+strings.Join(reasons, "，"))
+	}
+	return nil
+}
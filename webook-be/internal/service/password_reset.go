@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrResetTokenInvalid = repository.ErrResetTokenInvalid
+
+//go:generate go run go.uber.org/mock/mockgen -source=password_reset.go -package=svcmocks -destination=mocks/password_reset.mock.go PasswordResetService
+type PasswordResetService interface {
+	// SendResetMail 给邮箱发送重置密码的链接
+	// 邮箱没有注册时也返回 nil，避免被用来探测哪些邮箱注册过
+	// 同一个邮箱发送太频繁时返回 ErrMailSendTooMany
+	SendResetMail(ctx context.Context, email string) error
+	// Reset 用邮件中的 token 设置新密码，返回密码被重置的用户
+	Reset(ctx context.Context, token, password string) (int64, error)
+}
+
+type passwordResetService struct {
+	userRepo repository.UserRepository
+	repo     repository.PasswordResetRepository
+	mailSvc  mail.Service
+	// 按邮箱限制发送，为 nil 时不限流
+	limiter limiter.Limiter
+	policy  *PasswordPolicy
+	// 签名 token 的密钥，数据库泄露也无法伪造 token
+	secret []byte
+	// 链接的有效期
+	expiration time.Duration
+	// 前端重置密码页面的地址
+	resetURL string
+}
+
+func NewPasswordResetService(userRepo repository.UserRepository, repo repository.PasswordResetRepository,
+	mailSvc mail.Service, l limiter.Limiter, policy *PasswordPolicy,
+	secret []byte, expiration time.Duration, resetURL string) PasswordResetService {
+	return &passwordResetService{
+		userRepo:   userRepo,
+		repo:       repo,
+		mailSvc:    mailSvc,
+		limiter:    l,
+		policy:     policy,
+		secret:     secret,
+		expiration: expiration,
+		resetURL:   resetURL,
+	}
+}
+
+func (svc *passwordResetService) SendResetMail(ctx context.Context, email string) error {
+	if err := limitMail(ctx, svc.limiter, "reset", email); err != nil {
+		return err
+	}
+	u, err := svc.userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	if err = svc.repo.Create(ctx, u.Id, svc.sign(token), time.Now().Add(svc.expiration)); err != nil {
+		return err
+	}
+	link, err := url.Parse(svc.resetURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	body := fmt.Sprintf("你正在重置 webook 的密码，请在 %d 分钟内打开下面的链接设置新密码：\n\n%s\n\n如果不是你本人操作，请忽略这封邮件。",
+		int(svc.expiration.Minutes()), link.String())
+	return svc.mailSvc.Send(ctx, email, "重置 webook 密码", body)
+}
+
+// Reset 先校验新密码再使用 token，新密码不符合要求时用户还可以用同一个链接重试
+func (svc *passwordResetService) Reset(ctx context.Context, token, password string) (int64, error) {
+	tokenHash := svc.sign(token)
+	uid, err := svc.repo.FindUID(ctx, tokenHash)
+	if err != nil {
+		return 0, err
+	}
+	u, err := svc.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	if err = svc.policy.Check(password, u.Email); err != nil {
+		return 0, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	if err = svc.repo.Reset(ctx, tokenHash, hash); err != nil {
+		return 0, err
+	}
+	return uid, nil
+}
+
+func (svc *passwordResetService) sign(token string) string {
+	mac := hmac.New(sha256.New, svc.secret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,172 @@
+package service_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	repomocks "github.com/newton-miku/webook/webook-be/internal/repository/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	mailmocks "github.com/newton-miku/webook/webook-be/internal/service/mail/mocks"
+	limitermocks "github.com/newton-miku/webook/webook-be/pkg/limiter/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testResetSecret = []byte("reset-secret")
+
+func signToken(token string) string {
+	mac := hmac.New(sha256.New, testResetSecret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newResetService(ctrl *gomock.Controller) (service.PasswordResetService,
+	*repomocks.MockUserRepository, *repomocks.MockPasswordResetRepository, *mailmocks.MockService) {
+	userRepo := repomocks.NewMockUserRepository(ctrl)
+	repo := repomocks.NewMockPasswordResetRepository(ctrl)
+	mailSvc := mailmocks.NewMockService(ctrl)
+	policy := service.NewPasswordPolicy(service.PasswordPolicy{MinLength: 8, RequireLetter: true, RequireDigit: true})
+	svc := service.NewPasswordResetService(userRepo, repo, mailSvc, nil, policy,
+		testResetSecret, 30*time.Minute, "http://localhost:3000/users/reset_password")
+	return svc, userRepo, repo, mailSvc
+}
+
+func TestPasswordResetService_SendResetMail(t *testing.T) {
+	t.Run("邮箱没有注册时不发邮件", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, userRepo, _, _ := newResetService(ctrl)
+		userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{}, repository.ErrUserNotFound)
+
+		assert.NoError(t, svc.SendResetMail(context.Background(), "123@qq.com"))
+	})
+
+	t.Run("邮件中是明文 token，数据库中是签名", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		svc, userRepo, repo, mailSvc := newResetService(ctrl)
+		userRepo.EXPECT().FindByEmail(gomock.Any(), "123@qq.com").Return(domain.User{Id: 123}, nil)
+		var tokenHash string
+		repo.EXPECT().Create(gomock.Any(), int64(123), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, uid int64, hash string, expireAt time.Time) error {
+				tokenHash = hash
+				assert.WithinDuration(t, time.Now().Add(30*time.Minute), expireAt, time.Second)
+				return nil
+			})
+		var body string
+		mailSvc.EXPECT().Send(gomock.Any(), "123@qq.com", gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, to, subject, b string) error {
+				body = b
+				return nil
+			})
+
+		require.NoError(t, svc.SendResetMail(context.Background(), "123@qq.com"))
+		link := regexp.MustCompile(`http://\S+`).FindString(body)
+		u, err := url.Parse(link)
+		require.NoError(t, err)
+		token := u.Query().Get("token")
+		assert.NotEmpty(t, token)
+		assert.Equal(t, signToken(token), tokenHash)
+	})
+
+	t.Run("发送太频繁时不查询邮箱是否注册", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		l := limitermocks.NewMockLimiter(ctrl)
+		l.EXPECT().Limit(gomock.Any(), "mail-limiter:reset:123@qq.com").Return(true, nil)
+		svc := service.NewPasswordResetService(repomocks.NewMockUserRepository(ctrl),
+			repomocks.NewMockPasswordResetRepository(ctrl), mailmocks.NewMockService(ctrl), l,
+			service.NewPasswordPolicy(service.PasswordPolicy{MinLength: 8}),
+			testResetSecret, 30*time.Minute, "http://localhost:3000/users/reset_password")
+
+		assert.ErrorIs(t, svc.SendResetMail(context.Background(), "123@qq.com"), service.ErrMailSendTooMany)
+	})
+}
+
+func TestPasswordResetService_Reset(t *testing.T) {
+	const token = "token"
+	testCases := []struct {
+		name     string
+		mock     func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository)
+		password string
+		wantUID  int64
+		wantErr  error
+	}{
+		{
+			name: "重置成功",
+			mock: func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository) {
+				repo.EXPECT().FindUID(gomock.Any(), signToken(token)).Return(int64(123), nil)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(123)).Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+				repo.EXPECT().Reset(gomock.Any(), signToken(token), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, tokenHash string, hash []byte) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword(hash, []byte("hello#world1")))
+						return nil
+					})
+			},
+			password: "hello#world1",
+			wantUID:  123,
+		},
+		{
+			name: "token 无效",
+			mock: func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository) {
+				repo.EXPECT().FindUID(gomock.Any(), signToken(token)).Return(int64(0), repository.ErrResetTokenInvalid)
+			},
+			password: "hello#world1",
+			wantErr:  service.ErrResetTokenInvalid,
+		},
+		{
+			name: "新密码不符合策略时不使用 token",
+			mock: func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository) {
+				repo.EXPECT().FindUID(gomock.Any(), signToken(token)).Return(int64(123), nil)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(123)).Return(domain.User{Id: 123}, nil)
+			},
+			password: "hello",
+			wantErr:  service.ErrWeakPassword,
+		},
+		{
+			name: "并发使用时 token 已经被用掉",
+			mock: func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository) {
+				repo.EXPECT().FindUID(gomock.Any(), signToken(token)).Return(int64(123), nil)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(123)).Return(domain.User{Id: 123}, nil)
+				repo.EXPECT().Reset(gomock.Any(), signToken(token), gomock.Any()).Return(repository.ErrResetTokenInvalid)
+			},
+			password: "hello#world1",
+			wantErr:  service.ErrResetTokenInvalid,
+		},
+		{
+			name: "查询用户出错",
+			mock: func(userRepo *repomocks.MockUserRepository, repo *repomocks.MockPasswordResetRepository) {
+				repo.EXPECT().FindUID(gomock.Any(), signToken(token)).Return(int64(123), nil)
+				userRepo.EXPECT().FindByID(gomock.Any(), int64(123)).Return(domain.User{}, errors.New("随便一个错误"))
+			},
+			password: "hello#world1",
+			wantErr:  errors.New("随便一个错误"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			svc, userRepo, repo, _ := newResetService(ctrl)
+			tc.mock(userRepo, repo)
+
+			uid, err := svc.Reset(context.Background(), token, tc.password)
+			if tc.wantErr != nil {
+				if errors.Is(err, tc.wantErr) {
+					return
+				}
+				assert.EqualError(t, err, tc.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantUID, uid)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := NewPasswordPolicy(PasswordPolicy{
+		MinLength:     8,
+		RequireLetter: true,
+		RequireDigit:  true,
+		DisallowEmail: true,
+		CheckCommon:   true,
+	})
+	testCases := []struct {
+		name     string
+		password string
+		email    string
+		wantMsg  string
+	}{
+		{
+			name:     "符合策略",
+			password: "hello#world123",
+			email:    "miku@qq.com",
+		},
+		{
+			name:     "一次返回所有原因",
+			password: "hello",
+			wantMsg:  "密码长度不能少于8位，必须包含数字",
+		},
+		{
+			name:     "超过 bcrypt 的 72 字节",
+			password: strings.Repeat("a1", 37),
+			wantMsg:  "密码长度不能超过72个字节",
+		},
+		{
+			name:     "中文按字符计算长度",
+			password: "密码密码a1",
+			wantMsg:  "密码长度不能少于8位",
+		},
+		{
+			name:     "包含邮箱用户名",
+			password: "Miku2024abc",
+			email:    "miku@qq.com",
+			wantMsg:  "密码不能包含邮箱用户名",
+		},
+		{
+			name:     "邮箱用户名太短不检查",
+			password: "abc12345x",
+			email:    "ab@qq.com",
+		},
+		{
+			name:     "常见密码忽略大小写",
+			password: "Password123",
+			wantMsg:  "密码过于常见或者已经泄露，请换一个",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.password, tc.email)
+			if tc.wantMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrWeakPassword)
+			e, _ := errs.From(err)
+			assert.Equal(t, tc.wantMsg, e.Msg)
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package service
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(NewUserService, NewCodeService)
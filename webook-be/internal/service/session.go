@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+)
+
+// ErrRefreshTokenReused 用的不是最新的 refresh token，说明它可能已经泄露
+var ErrRefreshTokenReused = errors.New("refresh token 已经被使用过")
+
+//go:generate go run go.uber.org/mock/mockgen -source=session.go -package=svcmocks -destination=mocks/session.mock.go SessionService
+type SessionService interface {
+	// Create 记录一次新的登录，s.RefreshID 为签发的 refresh token 的 jti
+	Create(ctx context.Context, s domain.Session) error
+	// RotateRefresh 刷新 token 时把登录的 refresh token 从 oldID 换成 newID
+	// oldID 不是当前的 refresh token 时返回 ErrRefreshTokenReused，调用方应该让整个登录失效
+	RotateRefresh(ctx context.Context, ssid, oldID, newID string) error
+	// Touch 更新登录的最后活跃时间
+	Touch(ctx context.Context, uid int64, ssid string) error
+	// List 返回用户当前有效的登录，最近活跃的在前面
+	List(ctx context.Context, uid int64) ([]domain.Session, error)
+	// Remove 删除登录记录，调用方负责让对应的 token 失效
+	Remove(ctx context.Context, uid int64, ssids ...string) error
+}
+
+// 最后活跃时间只用于登录列表的展示，精确到分钟就够了
+// 每个请求都会调用 Touch，间隔内的请求不再访问 Redis
+const (
+	sessionTouchInterval = time.Minute
+	sessionTouchLRUSize  = 100000
+)
+
+type sessionService struct {
+	repo *repository.SessionRepository
+	// 超过这个时间没有活跃的登录视为已过期，和 refresh token 的有效期一致
+	expiration time.Duration
+	// 本实例最近更新过活跃时间的 ssid
+	touched *expirable.LRU[string, struct{}]
+}
+
+func NewSessionService(repo *repository.SessionRepository, expiration time.Duration) SessionService {
+	return &sessionService{
+		repo:       repo,
+		expiration: expiration,
+		touched:    expirable.NewLRU[string, struct{}](sessionTouchLRUSize, nil, sessionTouchInterval),
+	}
+}
+
+// Create 记录一次新的登录
+func (svc *sessionService) Create(ctx context.Context, s domain.Session) error {
+	now := time.Now().Unix()
+	s.LoginTime = now
+	s.LastSeen = now
+	return svc.repo.Save(ctx, s)
+}
+
+func (svc *sessionService) RotateRefresh(ctx context.Context, ssid, oldID, newID string) error {
+	ok, err := svc.repo.RotateRefresh(ctx, ssid, oldID, newID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// Touch 更新登录的最后活跃时间，同一个登录 sessionTouchInterval 内最多更新一次
+func (svc *sessionService) Touch(ctx context.Context, uid int64, ssid string) error {
+	if svc.touched.Contains(ssid) {
+		return nil
+	}
+	// 其它实例可能刚刚更新过，Redis 中还会再判断一次
+	err := svc.repo.Touch(ctx, uid, ssid, time.Now().Unix(), sessionTouchInterval)
+	if err != nil {
+		return err
+	}
+	svc.touched.Add(ssid, struct{}{})
+	return nil
+}
+
+// List 返回用户当前有效的登录，最近活跃的在前面
+func (svc *sessionService) List(ctx context.Context, uid int64) ([]domain.Session, error) {
+	sessions, err := svc.repo.FindByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(-svc.expiration).Unix()
+	res := make([]domain.Session, 0, len(sessions))
+	var expired []string
+	for _, s := range sessions {
+		if s.LastSeen < deadline {
+			expired = append(expired, s.Ssid)
+			continue
+		}
+		res = append(res, s)
+	}
+	// 顺手清理掉已经过期的登录，失败了也不影响结果
+	_ = svc.repo.Delete(ctx, uid, expired...)
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastSeen > res[j].LastSeen
+	})
+	return res, nil
+}
+
+// Remove 删除登录记录，调用方负责让对应的 token 失效
+func (svc *sessionService) Remove(ctx context.Context, uid int64, ssids ...string) error {
+	for _, ssid := range ssids {
+		svc.touched.Remove(ssid)
+	}
+	return svc.repo.Delete(ctx, uid, ssids...)
+}
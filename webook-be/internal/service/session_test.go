@@ -0,0 +1,26 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionService_Touch(t *testing.T) {
+	client, redisMock := redismock.NewClientMock()
+	// 只校验访问 Redis 的次数，脚本和参数由 Lua 保证
+	redisMock.Regexp().ExpectEval("(?s).*", []string{"users:sessions:123", "users:sessions:123:last_seen"},
+		"ssid-1", ".*", "60", ".*").SetVal(int64(1))
+	svc := service.NewSessionService(repository.NewSessionRepository(cache.NewSessionCache(client, time.Hour)), time.Hour)
+
+	assert.NoError(t, svc.Touch(context.Background(), 123, "ssid-1"))
+	// 一分钟内的第二次请求不访问 Redis
+	assert.NoError(t, svc.Touch(context.Background(), 123, "ssid-1"))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+)
+
+// Service 同步发送失败（限流或者服务商出错）时，把短信存入数据库，
+// 由后台任务异步重试
+type Service struct {
+	svc  sms.Service
+	repo *repository.AsyncSmsRepository
+	// 每条短信最多重试的次数
+	retryMax int
+}
+
+func NewService(svc sms.Service, repo *repository.AsyncSmsRepository, retryMax int) *Service {
+	return &Service{
+		svc:      svc,
+		repo:     repo,
+		retryMax: retryMax,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	err := s.svc.Send(ctx, tplId, args, numbers...)
+	if err == nil {
+		return nil
+	}
+	slog.Warn("短信同步发送失败，转为异步发送", slog.String("tpl", tplId), slog.Any("err", err))
+	return s.repo.Add(ctx, domain.AsyncSms{
+		TplId:    tplId,
+		Args:     args,
+		Numbers:  numbers,
+		RetryMax: s.retryMax,
+	})
+}
+
+// StartAsyncCycle 启动后台任务，直到 ctx 被取消
+func (s *Service) StartAsyncCycle(ctx context.Context) {
+	go func() {
+		for ctx.Err() == nil {
+			s.AsyncSend(ctx)
+		}
+	}()
+}
+
+// AsyncSend 抢占并发送一条等待中的短信
+func (s *Service) AsyncSend(ctx context.Context) {
+	preemptCtx, cancel := context.WithTimeout(ctx, time.Second)
+	as, err := s.repo.PreemptWaitingSMS(preemptCtx)
+	cancel()
+	switch {
+	case err == nil:
+	case errors.Is(err, repository.ErrWaitingSMSNotFound):
+		// 没有需要发送的短信，歇一会
+		s.sleep(ctx, time.Second)
+		return
+	case errors.Is(err, repository.ErrAsyncSmsPreempted):
+		// 被别的实例抢走了，直接找下一条
+		return
+	default:
+		slog.Error("抢占异步短信失败", slog.Any("err", err))
+		s.sleep(ctx, time.Second)
+		return
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	err = s.svc.Send(sendCtx, as.TplId, as.Args, as.Numbers...)
+	cancel()
+	if err != nil {
+		slog.Warn("异步发送短信失败", slog.Int64("id", as.Id), slog.String("tpl", as.TplId), slog.Any("err", err))
+	}
+	reportCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err = s.repo.ReportScheduleResult(reportCtx, as.Id, err == nil); err != nil {
+		slog.Error("更新异步短信状态失败", slog.Int64("id", as.Id), slog.Any("err", err))
+	}
+}
+
+func (s *Service) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
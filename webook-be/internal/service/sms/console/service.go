@@ -0,0 +1,19 @@
+package console
+
+import (
+	"context"
+	"log"
+)
+
+// Service 本地开发用的短信实现，只把短信内容打印出来
+type Service struct {
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	log.Printf("发送短信 模板: %s 参数: %v 手机号: %v\n", tplId, args, numbers)
+	return nil
+}
@@ -0,0 +1,44 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+)
+
+var ErrAllServiceFailed = errors.New("所有短信服务商都发送失败")
+
+// Service 在多个短信服务商之间轮询，一个失败了就换下一个
+type Service struct {
+	svcs []sms.Service
+	// 下一次从哪个服务商开始尝试
+	idx uint64
+}
+
+func NewService(svcs []sms.Service) *Service {
+	return &Service{
+		svcs: svcs,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	// AddUint64 返回加一之后的值，第一次发送从第 0 个服务商开始
+	idx := atomic.AddUint64(&s.idx, 1) - 1
+	length := uint64(len(s.svcs))
+	for i := idx; i < idx+length; i++ {
+		svc := s.svcs[i%length]
+		err := svc.Send(ctx, tplId, args, numbers...)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			// 调用者已经放弃了，没必要再试
+			return err
+		}
+		slog.Warn("短信服务商发送失败", slog.Uint64("idx", i%length), slog.String("tpl", tplId), slog.Any("err", err))
+	}
+	return ErrAllServiceFailed
+}
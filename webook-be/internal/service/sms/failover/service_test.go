@@ -0,0 +1,74 @@
+package failover_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/failover"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenService 前 failCnt 次发送都会失败
+type brokenService struct {
+	failCnt int
+	called  int
+}
+
+func (s *brokenService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	s.called++
+	if s.called <= s.failCnt {
+		return errors.New("服务商不可用")
+	}
+	return nil
+}
+
+func TestFailover(t *testing.T) {
+	testCases := []struct {
+		name string
+		svcs []*brokenService
+		// 发送的次数
+		sendCnt int
+		// 每个服务商被调用的次数
+		wantCalled []int
+		wantErr    error
+	}{
+		{
+			name:       "第一个服务商失败，切换到下一个",
+			svcs:       []*brokenService{{failCnt: 1}, {}},
+			sendCnt:    1,
+			wantCalled: []int{1, 1},
+		},
+		{
+			name:       "轮询，第二次从下一个服务商开始",
+			svcs:       []*brokenService{{}, {}},
+			sendCnt:    2,
+			wantCalled: []int{1, 1},
+		},
+		{
+			name:       "全部失败",
+			svcs:       []*brokenService{{failCnt: 1}, {failCnt: 1}},
+			sendCnt:    1,
+			wantCalled: []int{1, 1},
+			wantErr:    failover.ErrAllServiceFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcs := make([]sms.Service, 0, len(tc.svcs))
+			for _, s := range tc.svcs {
+				svcs = append(svcs, s)
+			}
+			svc := failover.NewService(svcs)
+			var err error
+			for range tc.sendCnt {
+				err = svc.Send(context.Background(), "tpl", []string{"123456"}, "15212345678")
+			}
+			assert.Equal(t, tc.wantErr, err)
+			for i, s := range tc.svcs {
+				assert.Equal(t, tc.wantCalled[i], s.called, "第 %d 个服务商", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+// Message 一条已发送的短信
+type Message struct {
+	TplId  string
+	Args   []string
+	Number string
+}
+
+// Service 把短信保存在内存中，不会真正发送
+// 用于 CI 和本地联调，可以通过 Messages 查看发过的短信
+type Service struct {
+	mu   sync.RWMutex
+	msgs []Message
+}
+
+func NewService() *Service {
+	return &Service{}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, number := range numbers {
+		s.msgs = append(s.msgs, Message{
+			TplId:  tplId,
+			Args:   args,
+			Number: number,
+		})
+	}
+	return nil
+}
+
+// Messages 返回所有已发送的短信
+func (s *Service) Messages() []Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]Message, len(s.msgs))
+	copy(res, s.msgs)
+	return res
+}
+
+// Last 返回发给 number 的最后一条短信
+func (s *Service) Last(number string) (Message, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.msgs) - 1; i >= 0; i-- {
+		if s.msgs[i].Number == number {
+			return s.msgs[i], true
+		}
+	}
+	return Message{}, false
+}
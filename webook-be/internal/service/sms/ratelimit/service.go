@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+)
+
+var ErrLimited = errors.New("短信服务触发了限流")
+
+// Service 在调用服务商之前先做限流，避免超出服务商的配额
+type Service struct {
+	svc     sms.Service
+	limiter limiter.Limiter
+	key     string
+}
+
+func NewService(svc sms.Service, l limiter.Limiter) *Service {
+	return &Service{
+		svc:     svc,
+		limiter: l,
+		key:     "sms-limiter",
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	limited, err := s.limiter.Limit(ctx, s.key)
+	if err != nil {
+		return err
+	}
+	if limited {
+		return ErrLimited
+	}
+	return s.svc.Send(ctx, tplId, args, numbers...)
+}
@@ -0,0 +1,37 @@
+package retryable
+
+import (
+	"context"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+)
+
+// Service 发送失败时按固定间隔重试
+type Service struct {
+	svc sms.Service
+	// 最多重试次数，不包含第一次发送
+	retryMax int
+	interval time.Duration
+}
+
+func NewService(svc sms.Service, retryMax int, interval time.Duration) *Service {
+	return &Service{
+		svc:      svc,
+		retryMax: retryMax,
+		interval: interval,
+	}
+}
+
+func (s *Service) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	err := s.svc.Send(ctx, tplId, args, numbers...)
+	for i := 0; err != nil && i < s.retryMax; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+		err = s.svc.Send(ctx, tplId, args, numbers...)
+	}
+	return err
+}
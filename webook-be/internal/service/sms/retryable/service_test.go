@@ -0,0 +1,70 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/memory"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/retryable"
+	"github.com/stretchr/testify/assert"
+)
+
+// brokenService 前 failCnt 次发送都会失败
+type brokenService struct {
+	failCnt int
+	called  int
+}
+
+func (s *brokenService) Send(ctx context.Context, tplId string, args []string, numbers ...string) error {
+	s.called++
+	if s.called <= s.failCnt {
+		return errors.New("服务商不可用")
+	}
+	return nil
+}
+
+func TestService_Send(t *testing.T) {
+	testCases := []struct {
+		name       string
+		failCnt    int
+		wantCalled int
+		wantErr    bool
+	}{
+		{
+			name:       "第一次就成功",
+			wantCalled: 1,
+		},
+		{
+			name:       "重试后成功",
+			failCnt:    2,
+			wantCalled: 3,
+		},
+		{
+			name:       "超过重试次数",
+			failCnt:    3,
+			wantCalled: 3,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			broken := &brokenService{failCnt: tc.failCnt}
+			svc := retryable.NewService(broken, 2, time.Millisecond)
+			err := svc.Send(context.Background(), "tpl", []string{"123456"}, "15212345678")
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantCalled, broken.called)
+		})
+	}
+}
+
+func TestService_SendArgs(t *testing.T) {
+	mem := memory.NewService()
+	svc := retryable.NewService(mem, 2, time.Millisecond)
+	err := svc.Send(context.Background(), "tpl", []string{"654321"}, "15212345678")
+	assert.NoError(t, err)
+	msg, ok := mem.Last("15212345678")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"654321"}, msg.Args)
+}
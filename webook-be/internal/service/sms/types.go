@@ -0,0 +1,9 @@
+package sms
+
+import "context"
+
+// Service 发送短信的抽象
+// tplId 为短信模板 id，args 为模板参数，numbers 为接收短信的手机号
+type Service interface {
+	Send(ctx context.Context, tplId string, args []string, numbers ...string) error
+}
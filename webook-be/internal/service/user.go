@@ -4,44 +4,72 @@ import (
 	"context"
 	"errors"
 
-	"github.com/gin-gonic/gin"
 	"github.com/newton-miku/webook/webook-be/internal/domain"
 	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrUserDuplicateEmail    = repository.ErrUserDuplicateEmail
-	ErrInvalidUserOrPassword = errors.New("邮箱或者密码不正确")
+	ErrInvalidUserOrPassword = errs.New(errs.UserInvalidCredential, "邮箱或者密码不正确")
 	ErrProfileNotFound       = repository.ErrUserProfileNotFound
+	ErrProfileConflict       = repository.ErrUserProfileConflict
+	ErrMergeConflict         = repository.ErrUserMergeConflict
 	ErrUserNotFound          = repository.ErrUserNotFound
+	// 要绑定的登录方式属于另一个账号，需要用户确认合并
+	ErrIdentityBoundToOther = errs.New(errs.UserIdentityBoundToOther, "该登录方式已绑定其它账号，是否合并账号")
+	// 同一种登录方式只能绑定一个，需要先解绑
+	ErrIdentityAlreadyBound = errs.New(errs.UserIdentityAlreadyBound, "已经绑定过该类型的登录方式，请先解绑")
+	ErrLastIdentity         = errs.New(errs.UserLastIdentity, "至少需要保留一种登录方式")
+	ErrWrongPassword        = errs.New(errs.UserWrongPassword, "原密码不正确")
 )
 
-type UserService struct {
-	repo *repository.UserRepository
+//go:generate go run go.uber.org/mock/mockgen -source=user.go -package=svcmocks -destination=mocks/user.mock.go UserService
+type UserService interface {
+	SignUp(ctx context.Context, u domain.User) error
+	Login(ctx context.Context, user domain.User) (domain.User, error)
+	// FindOrCreateByPhone 手机号登录，用户不存在时直接注册
+	FindOrCreateByPhone(ctx context.Context, phone string) (domain.User, error)
+	// FindOrCreateByWechat 微信登录，用户不存在时直接注册
+	FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error)
+	Profile(ctx context.Context, uid int64) (domain.UserProfile, error)
+	// UpdateProfile 只修改不为 nil 的字段，返回修改后的档案
+	// 档案在客户端读取之后被修改过时返回 ErrProfileConflict
+	UpdateProfile(ctx context.Context, p domain.UserProfilePatch) (domain.UserProfile, error)
+	// BindPhone 给已登录的用户绑定手机号，调用前需要先校验验证码
+	// 手机号已经属于另一个账号时，merge 为 true 则把那个账号合并到当前账号
+	// 返回被合并掉的账号 id，没有合并时为 0，调用方负责让那个账号的登录失效
+	// 两个账号有同一种登录方式或者对方开启了两步验证时不能合并，返回 ErrMergeConflict
+	BindPhone(ctx context.Context, uid int64, phone string, merge bool) (int64, error)
+	// BindWechat 给已登录的用户绑定微信，规则和 BindPhone 相同
+	BindWechat(ctx context.Context, uid int64, info domain.WechatInfo, merge bool) (int64, error)
+	UnbindPhone(ctx context.Context, uid int64) error
+	UnbindWechat(ctx context.Context, uid int64) error
+	// ChangePassword 校验原密码后设置新密码
+	ChangePassword(ctx context.Context, uid int64, oldPassword, newPassword string) error
 }
 
-func (svc *UserService) UpdateProfile(ctx *gin.Context, u domain.UserProfile) error {
-	return svc.repo.UpdateProfile(ctx, u)
+type userService struct {
+	repo   repository.UserRepository
+	policy *PasswordPolicy
 }
 
-func (svc *UserService) Profile(ctx context.Context, i int64) (domain.UserProfile, error) {
-	// 先找用户
-	u, err := svc.repo.FindProfileByID(ctx, i)
-	if err != nil {
-		// 用户档案不存在
-		if errors.Is(err, ErrProfileNotFound) {
-			return domain.UserProfile{}, ErrProfileNotFound
-		}
-		return domain.UserProfile{}, errors.New("查询档案时发生异常")
-	}
-	return u, nil
+func (svc *userService) UpdateProfile(ctx context.Context, p domain.UserProfilePatch) (domain.UserProfile, error) {
+	return svc.repo.UpdateProfile(ctx, p)
+}
+
+func (svc *userService) Profile(ctx context.Context, i int64) (domain.UserProfile, error) {
+	return svc.repo.FindProfileByID(ctx, i)
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.UserRepository, policy *PasswordPolicy) UserService {
+	return &userService{repo: repo, policy: policy}
 }
-func (svc *UserService) SignUp(ctx context.Context, u domain.User) error {
+func (svc *userService) SignUp(ctx context.Context, u domain.User) error {
+	if err := svc.policy.Check(string(u.Password), u.Email); err != nil {
+		return err
+	}
 	hash, err := bcrypt.GenerateFromPassword(u.Password, bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -50,7 +78,139 @@ func (svc *UserService) SignUp(ctx context.Context, u domain.User) error {
 	return svc.repo.Create(ctx, u)
 }
 
-func (svc *UserService) Login(ctx context.Context, user domain.User) (domain.User, error) {
+// FindOrCreateByPhone 手机号登录，用户不存在时直接注册
+func (svc *userService) FindOrCreateByPhone(ctx context.Context, phone string) (domain.User, error) {
+	u, err := svc.repo.FindByPhone(ctx, phone)
+	if !errors.Is(err, ErrUserNotFound) {
+		// 找到了用户，或者查询时出错
+		return u, err
+	}
+	err = svc.repo.Create(ctx, domain.User{
+		Phone: phone,
+	})
+	// 手机号冲突说明并发注册了，直接再查一次
+	if err != nil && !errors.Is(err, repository.ErrUserDuplicatePhone) {
+		return domain.User{}, err
+	}
+	return svc.repo.FindByPhone(ctx, phone)
+}
+
+// FindOrCreateByWechat 微信登录，用户不存在时直接注册
+func (svc *userService) FindOrCreateByWechat(ctx context.Context, info domain.WechatInfo) (domain.User, error) {
+	u, err := svc.repo.FindByWechat(ctx, info)
+	if !errors.Is(err, ErrUserNotFound) {
+		return u, err
+	}
+	err = svc.repo.Create(ctx, domain.User{
+		WechatInfo: info,
+	})
+	if err != nil && !errors.Is(err, repository.ErrUserDuplicateWechat) {
+		return domain.User{}, err
+	}
+	return svc.repo.FindByWechat(ctx, info)
+}
+
+// BindPhone 给已登录的用户绑定手机号，调用前需要先校验验证码
+// 手机号已经属于另一个账号时，merge 为 true 则把那个账号合并到当前账号
+func (svc *userService) BindPhone(ctx context.Context, uid int64, phone string, merge bool) (int64, error) {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	if u.Phone == phone {
+		return 0, nil
+	}
+	if u.Phone != "" {
+		return 0, ErrIdentityAlreadyBound
+	}
+	owner, err := svc.repo.FindByPhone(ctx, phone)
+	return svc.bindOrMerge(ctx, uid, owner, err, merge, func() error {
+		return svc.repo.BindPhone(ctx, uid, phone)
+	})
+}
+
+// BindWechat 给已登录的用户绑定微信，规则和 BindPhone 相同
+func (svc *userService) BindWechat(ctx context.Context, uid int64, info domain.WechatInfo, merge bool) (int64, error) {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	if u.WechatInfo.OpenID == info.OpenID {
+		return 0, nil
+	}
+	if u.WechatInfo.OpenID != "" {
+		return 0, ErrIdentityAlreadyBound
+	}
+	owner, err := svc.repo.FindByWechat(ctx, info)
+	return svc.bindOrMerge(ctx, uid, owner, err, merge, func() error {
+		return svc.repo.BindWechat(ctx, uid, info)
+	})
+}
+
+// bindOrMerge owner 为当前持有该登录方式的账号，findErr 为查找 owner 时的错误
+// 合并时返回 owner 的 id
+func (svc *userService) bindOrMerge(ctx context.Context, uid int64, owner domain.User, findErr error,
+	merge bool, bind func() error) (int64, error) {
+	switch {
+	case errors.Is(findErr, ErrUserNotFound):
+		return 0, bind()
+	case findErr != nil:
+		return 0, findErr
+	case !merge:
+		return 0, ErrIdentityBoundToOther
+	default:
+		// 当前账号没有这种登录方式，合并后就会转移到当前账号上
+		if err := svc.repo.Merge(ctx, uid, owner.Id); err != nil {
+			return 0, err
+		}
+		return owner.Id, nil
+	}
+}
+
+func (svc *userService) UnbindPhone(ctx context.Context, uid int64) error {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.Phone == "" {
+		return nil
+	}
+	if svc.loginMethodCnt(u) <= 1 {
+		return ErrLastIdentity
+	}
+	return svc.repo.UnbindPhone(ctx, uid)
+}
+
+func (svc *userService) UnbindWechat(ctx context.Context, uid int64) error {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if u.WechatInfo.OpenID == "" {
+		return nil
+	}
+	if svc.loginMethodCnt(u) <= 1 {
+		return ErrLastIdentity
+	}
+	return svc.repo.UnbindWechat(ctx, uid)
+}
+
+// loginMethodCnt 用户可以使用的登录方式数量
+func (svc *userService) loginMethodCnt(u domain.User) int {
+	cnt := 0
+	if u.Email != "" && len(u.Password) > 0 {
+		cnt++
+	}
+	if u.Phone != "" {
+		cnt++
+	}
+	if u.WechatInfo.OpenID != "" {
+		cnt++
+	}
+	return cnt
+}
+
+func (svc *userService) Login(ctx context.Context, user domain.User) (domain.User, error) {
 	// 先找用户
 	u, err := svc.repo.FindByEmail(ctx, user.Email)
 	if err != nil {
@@ -58,7 +218,7 @@ func (svc *UserService) Login(ctx context.Context, user domain.User) (domain.Use
 		if errors.Is(err, ErrUserNotFound) {
 			return domain.User{}, ErrInvalidUserOrPassword
 		}
-		return domain.User{}, errors.New("登录时系统发生异常")
+		return domain.User{}, err
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(user.Password))
 	if err != nil {
@@ -67,3 +227,23 @@ func (svc *UserService) Login(ctx context.Context, user domain.User) (domain.Use
 	}
 	return u, nil
 }
+
+// ChangePassword 校验原密码后设置新密码
+// 手机号、微信注册的账号没有密码，原密码怎么填都不对
+func (svc *userService) ChangePassword(ctx context.Context, uid int64, oldPassword, newPassword string) error {
+	u, err := svc.repo.FindByID(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword(u.Password, []byte(oldPassword)) != nil {
+		return ErrWrongPassword
+	}
+	if err = svc.policy.Check(newPassword, u.Email); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return svc.repo.UpdatePassword(ctx, uid, hash)
+}
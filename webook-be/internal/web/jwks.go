@@ -0,0 +1,29 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+)
+
+// JWKSHandler 公开校验 access token 使用的公钥，其它服务不需要共享密钥就能校验 webook 的 token
+type JWKSHandler struct {
+	hdl ijwt.Handler
+}
+
+func NewJWKSHandler(hdl ijwt.Handler) *JWKSHandler {
+	return &JWKSHandler{
+		hdl: hdl,
+	}
+}
+
+func (h *JWKSHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/.well-known/jwks.json", h.JWKS)
+}
+
+func (h *JWKSHandler) JWKS(ctx *gin.Context) {
+	// 允许校验方缓存一段时间，轮换密钥时需要提前发布新的公钥
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.hdl.JWKS())
+}
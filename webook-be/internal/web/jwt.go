@@ -0,0 +1,198 @@
+package web
+
+import (
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
+)
+
+var (
+	errEmptyUserAgent      = errs.New(errs.InvalidParam, "Go Away")
+	errInvalidRefreshToken = errors.New("refresh token 无效")
+)
+
+// 登录方式
+const (
+	loginMethodEmail  = "email"
+	loginMethodSMS    = "sms"
+	loginMethodWechat = "wechat"
+)
+
+type jwtHandler struct {
+	hdl        ijwt.Handler
+	blacklist  *middleware.SessionBlacklist
+	sessionSvc service.SessionService
+	mfaSvc     service.MFAService
+}
+
+func newJWTHandler(hdl ijwt.Handler, blacklist *middleware.SessionBlacklist,
+	sessionSvc service.SessionService, mfaSvc service.MFAService) jwtHandler {
+	return jwtHandler{
+		hdl:        hdl,
+		blacklist:  blacklist,
+		sessionSvc: sessionSvc,
+		mfaSvc:     mfaSvc,
+	}
+}
+
+type MFAPendingVO struct {
+	// 调用 /users/login/mfa 完成登录时带上
+	MFAToken string `json:"mfaToken"`
+}
+
+// login 第一步验证通过后调用
+// 开启了两步验证时不签发 token，返回 errs.UserMFARequired 和 mfaToken，由 /users/login/mfa 完成登录
+func (h jwtHandler) login(ctx *gin.Context, uid int64, method string) (ginx.Result, error) {
+	mfaToken, err := h.mfaSvc.BeginLogin(ctx, uid, method)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	if mfaToken != "" {
+		return ginx.Result{
+			Code: errs.UserMFARequired,
+			Msg:  "请输入两步验证码",
+			Data: MFAPendingVO{MFAToken: mfaToken},
+		}, nil
+	}
+	if err = h.setLoginToken(ctx, uid, method); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "登录成功"}, nil
+}
+
+// setLoginToken 登录成功后调用，同时签发 access token 和 refresh token，并记录这次登录
+// method 为登录方式
+func (h jwtHandler) setLoginToken(ctx *gin.Context, uid int64, method string) error {
+	if ctx.Request.UserAgent() == "" {
+		// ua为空或者无ua头
+		return errEmptyUserAgent
+	}
+	ssid, refreshID := rand.Text(), rand.Text()
+	// 先记录登录，记录失败时不能把 token 返回给前端
+	err := h.sessionSvc.Create(ctx, domain.Session{
+		Ssid:      ssid,
+		Uid:       uid,
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+		Method:    method,
+		RefreshID: refreshID,
+	})
+	if err != nil {
+		return err
+	}
+	if err = h.setJWTToken(ctx, uid, ssid); err != nil {
+		return err
+	}
+	return h.setRefreshToken(ctx, uid, ssid, refreshID)
+}
+
+// setJWTToken 签发 access token 并通过 X-JWT-Token 返回给前端
+func (h jwtHandler) setJWTToken(ctx *gin.Context, uid int64, ssid string) error {
+	claims := middleware.JWTClaims{
+		UserId:    uid,
+		UserAgent: ctx.Request.UserAgent(),
+		Ssid:      ssid,
+	}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(middleware.JWTExpire))
+	tokenStr, err := h.hdl.SignAccessToken(claims)
+	if err != nil {
+		return err
+	}
+	ctx.Header("X-JWT-Token", tokenStr)
+	return nil
+}
+
+// setRefreshToken 签发 refresh token 并通过 X-Refresh-Token 返回给前端
+// refreshID 作为 jti，每个登录同时只有最新签发的 refresh token 有效
+func (h jwtHandler) setRefreshToken(ctx *gin.Context, uid int64, ssid, refreshID string) error {
+	claims := middleware.RefreshClaims{
+		UserId:    uid,
+		UserAgent: ctx.Request.UserAgent(),
+		Ssid:      ssid,
+	}
+	claims.ID = refreshID
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(middleware.RefreshExpire))
+	tokenStr, err := h.hdl.SignRefreshToken(claims)
+	if err != nil {
+		return err
+	}
+	ctx.Header("X-Refresh-Token", tokenStr)
+	return nil
+}
+
+// parseRefreshToken 校验请求中携带的 refresh token
+func (h jwtHandler) parseRefreshToken(ctx *gin.Context) (*middleware.RefreshClaims, error) {
+	claims := &middleware.RefreshClaims{}
+	err := h.hdl.ParseRefreshToken(middleware.ExtractToken(ctx), claims)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserId == 0 || claims.Ssid == "" || claims.ID == "" {
+		return nil, errInvalidRefreshToken
+	}
+	if claims.UserAgent != ctx.Request.UserAgent() {
+		return nil, errInvalidRefreshToken
+	}
+	revoked, err := h.blacklist.Contains(ctx, claims.Ssid)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errInvalidRefreshToken
+	}
+	return claims, nil
+}
+
+// clearToken 退出登录，把当前的 ssid 加入黑名单
+// access token 和 refresh token 共用 ssid，会一起失效
+func (h jwtHandler) clearToken(ctx *gin.Context, claims *middleware.JWTClaims) error {
+	ctx.Header("X-JWT-Token", "")
+	ctx.Header("X-Refresh-Token", "")
+	return h.revokeSessions(ctx, claims.UserId, claims.Ssid)
+}
+
+// revokeAllSessions 让 uid 除 except 以外的所有登录失效，except 为空时全部失效
+func (h jwtHandler) revokeAllSessions(ctx *gin.Context, uid int64, except string) error {
+	sessions, err := h.sessionSvc.List(ctx, uid)
+	if err != nil {
+		return err
+	}
+	ssids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		if s.Ssid != except {
+			ssids = append(ssids, s.Ssid)
+		}
+	}
+	if len(ssids) == 0 {
+		return nil
+	}
+	return h.revokeSessions(ctx, uid, ssids...)
+}
+
+// revokeMerged 账号被合并后已经删除，它的登录也要全部失效
+// mergedID 为 0 表示没有发生合并
+func (h jwtHandler) revokeMerged(ctx *gin.Context, mergedID int64) error {
+	if mergedID == 0 {
+		return nil
+	}
+	return h.revokeAllSessions(ctx, mergedID, "")
+}
+
+// revokeSessions 让 uid 的这些登录失效
+func (h jwtHandler) revokeSessions(ctx *gin.Context, uid int64, ssids ...string) error {
+	for _, ssid := range ssids {
+		if err := h.blacklist.Add(ctx, ssid); err != nil {
+			return err
+		}
+	}
+	return h.sessionSvc.Remove(ctx, uid, ssids...)
+}
@@ -0,0 +1,47 @@
+package jwt
+
+import gojwt "github.com/golang-jwt/jwt/v5"
+
+// Handler 负责签发和校验登录用的 token
+// access token 可以交给其它服务用 JWKS 中的公钥校验，refresh token 只有 webook 自己使用
+type Handler interface {
+	SignAccessToken(claims gojwt.Claims) (string, error)
+	ParseAccessToken(tokenStr string, claims gojwt.Claims) error
+	SignRefreshToken(claims gojwt.Claims) (string, error)
+	ParseRefreshToken(tokenStr string, claims gojwt.Claims) error
+	// JWKS 校验 access token 使用的公钥
+	JWKS() JWKS
+}
+
+type keySetHandler struct {
+	access  *KeySet
+	refresh *KeySet
+}
+
+// NewHandler access 和 refresh 使用不同的密钥，避免 refresh token 被当作 access token 使用
+func NewHandler(access, refresh *KeySet) Handler {
+	return &keySetHandler{
+		access:  access,
+		refresh: refresh,
+	}
+}
+
+func (h *keySetHandler) SignAccessToken(claims gojwt.Claims) (string, error) {
+	return h.access.Sign(claims)
+}
+
+func (h *keySetHandler) ParseAccessToken(tokenStr string, claims gojwt.Claims) error {
+	return h.access.Parse(tokenStr, claims)
+}
+
+func (h *keySetHandler) SignRefreshToken(claims gojwt.Claims) (string, error) {
+	return h.refresh.Sign(claims)
+}
+
+func (h *keySetHandler) ParseRefreshToken(tokenStr string, claims gojwt.Claims) error {
+	return h.refresh.Parse(tokenStr, claims)
+}
+
+func (h *keySetHandler) JWKS() JWKS {
+	return h.access.JWKS()
+}
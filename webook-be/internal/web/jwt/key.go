@@ -0,0 +1,206 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnsupportedAlg = errors.New("不支持的签名算法")
+	ErrKeyNotFound    = errors.New("未找到对应 kid 的密钥")
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS512 = "HS512"
+)
+
+// KeyConfig 一把密钥的配置
+type KeyConfig struct {
+	Kid string
+	// RS256、EdDSA 或者 HS512
+	Alg string
+	// PEM 格式的私钥文件，配置了私钥的密钥才能用来签名
+	PrivateKeyFile string
+	// PEM 格式的公钥文件，轮换密钥时保留旧的公钥，用来校验还没过期的 token
+	PublicKeyFile string
+	// HS512 使用的密钥
+	Secret string
+}
+
+// Key 签名或者校验 token 使用的密钥
+type Key struct {
+	Kid    string
+	Method gojwt.SigningMethod
+	// 为 nil 时只能用来校验
+	signKey   any
+	verifyKey any
+}
+
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// LoadKey 根据配置加载密钥
+func LoadKey(cfg KeyConfig) (*Key, error) {
+	switch cfg.Alg {
+	case AlgHS512:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("密钥 %s 没有配置 Secret", cfg.Kid)
+		}
+		return NewHMACKey(cfg.Kid, []byte(cfg.Secret)), nil
+	case AlgRS256, AlgEdDSA:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, cfg.Alg)
+	}
+	if cfg.PrivateKeyFile != "" {
+		block, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newAsymmetricKey(cfg.Kid, cfg.Alg, priv)
+	}
+	if cfg.PublicKeyFile != "" {
+		block, err := readPEM(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newAsymmetricKey(cfg.Kid, cfg.Alg, pub)
+	}
+	return nil, fmt.Errorf("密钥 %s 没有配置私钥或者公钥", cfg.Kid)
+}
+
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{
+		Kid:       kid,
+		Method:    gojwt.SigningMethodHS512,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// GenerateEd25519Key 生成一把临时的密钥，重启后之前签发的 token 全部失效，只用于本地开发
+func GenerateEd25519Key(kid string) (*Key, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newAsymmetricKey(kid, AlgEdDSA, priv)
+}
+
+// newAsymmetricKey k 可以是私钥也可以是公钥，是公钥时只能用来校验
+func newAsymmetricKey(kid, alg string, k any) (*Key, error) {
+	key := &Key{Kid: kid}
+	switch v := k.(type) {
+	case *rsa.PrivateKey:
+		key.signKey, key.verifyKey = v, &v.PublicKey
+	case *rsa.PublicKey:
+		key.verifyKey = v
+	case ed25519.PrivateKey:
+		key.signKey, key.verifyKey = v, v.Public()
+	case ed25519.PublicKey:
+		key.verifyKey = v
+	default:
+		return nil, fmt.Errorf("密钥 %s 的类型 %T 不支持", kid, k)
+	}
+	switch key.verifyKey.(type) {
+	case *rsa.PublicKey:
+		if alg != AlgRS256 {
+			return nil, fmt.Errorf("密钥 %s 是 RSA 密钥，但是算法配置为 %s", kid, alg)
+		}
+		key.Method = gojwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		if alg != AlgEdDSA {
+			return nil, fmt.Errorf("密钥 %s 是 Ed25519 密钥，但是算法配置为 %s", kid, alg)
+		}
+		key.Method = gojwt.SigningMethodEdDSA
+	}
+	return key, nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("文件 %s 不是 PEM 格式", file)
+	}
+	return block, nil
+}
+
+// KeySet 一组密钥，其中一把用来签名，所有的密钥都可以用来校验
+// 轮换时先加入新的密钥并切换签名密钥，等旧 token 过期后再移除旧的密钥
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+func NewKeySet(signingKid string, keys ...*Key) (*KeySet, error) {
+	ks := &KeySet{
+		keys: make(map[string]*Key, len(keys)),
+	}
+	for _, k := range keys {
+		if _, ok := ks.keys[k.Kid]; ok {
+			return nil, fmt.Errorf("kid %s 重复", k.Kid)
+		}
+		ks.keys[k.Kid] = k
+	}
+	signing, ok := ks.keys[signingKid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, signingKid)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("签名密钥 %s 没有私钥", signingKid)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// Sign 使用签名密钥签发 token，header 中带上 kid
+func (ks *KeySet) Sign(claims gojwt.Claims) (string, error) {
+	token := gojwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.Kid
+	return token.SignedString(ks.signing.signKey)
+}
+
+// Parse 根据 header 中的 kid 找到对应的密钥校验 token
+func (ks *KeySet) Parse(tokenStr string, claims gojwt.Claims) error {
+	token, err := gojwt.ParseWithClaims(tokenStr, claims, func(token *gojwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrKeyNotFound
+		}
+		// 防止攻击者修改 alg，比如拿公钥当 HMAC 的密钥
+		if token.Method.Alg() != k.Method.Alg() {
+			return nil, ErrUnsupportedAlg
+		}
+		return k.verifyKey, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return gojwt.ErrTokenSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey, err := ijwt.GenerateEd25519Key("old")
+	require.NoError(t, err)
+	newKey, err := ijwt.GenerateEd25519Key("new")
+	require.NoError(t, err)
+
+	before, err := ijwt.NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	tokenStr, err := before.Sign(newClaims())
+	require.NoError(t, err)
+
+	// 轮换后用新密钥签名，旧密钥签发的 token 仍然可以校验
+	after, err := ijwt.NewKeySet("new", oldKey, newKey)
+	require.NoError(t, err)
+	assert.NoError(t, after.Parse(tokenStr, &gojwt.RegisteredClaims{}))
+
+	// 旧密钥移除后旧 token 失效
+	removed, err := ijwt.NewKeySet("new", newKey)
+	require.NoError(t, err)
+	assert.ErrorIs(t, removed.Parse(tokenStr, &gojwt.RegisteredClaims{}), ijwt.ErrKeyNotFound)
+}
+
+func TestKeySetRejectAlgMismatch(t *testing.T) {
+	// 攻击者用同一个 kid 换成 HMAC 签名
+	secret := []byte("secret")
+	token := gojwt.NewWithClaims(gojwt.SigningMethodHS512, newClaims())
+	token.Header["kid"] = "ed"
+	tokenStr, err := token.SignedString(secret)
+	require.NoError(t, err)
+
+	k, err := ijwt.GenerateEd25519Key("ed")
+	require.NoError(t, err)
+	ks, err := ijwt.NewKeySet("ed", k)
+	require.NoError(t, err)
+	assert.ErrorIs(t, ks.Parse(tokenStr, &gojwt.RegisteredClaims{}), ijwt.ErrUnsupportedAlg)
+}
+
+func TestLoadKeyAndJWKS(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "rsa.pem")
+	err = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	require.NoError(t, err)
+
+	rsaKey, err := ijwt.LoadKey(ijwt.KeyConfig{Kid: "rsa", Alg: ijwt.AlgRS256, PrivateKeyFile: file})
+	require.NoError(t, err)
+	_, err = ijwt.LoadKey(ijwt.KeyConfig{Kid: "rsa", Alg: ijwt.AlgEdDSA, PrivateKeyFile: file})
+	assert.Error(t, err)
+
+	ks, err := ijwt.NewKeySet("rsa", rsaKey, ijwt.NewHMACKey("hmac", []byte("secret")))
+	require.NoError(t, err)
+	jwks := ks.JWKS()
+	// 对称密钥不能公开
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "rsa", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+}
+
+func newClaims() gojwt.RegisteredClaims {
+	return gojwt.RegisteredClaims{
+		ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
+)
+
+var errNoClaims = errors.New("未找到登录信息")
+
+// EmailVerifiedChecker 检查用户是否可以发帖，service.EmailVerifyService 实现了这个接口
+type EmailVerifiedChecker interface {
+	CheckPost(ctx context.Context, uid int64) error
+}
+
+// RequireVerifiedEmail 挂在发帖等需要验证邮箱的路由上，需要放在登录校验之后
+func RequireVerifiedEmail(checker EmailVerifiedChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		val, _ := ctx.Get(ginx.ClaimsKey)
+		claims, ok := val.(*JWTClaims)
+		if !ok {
+			ginx.Render(ctx, ginx.Result{}, errNoClaims)
+			ctx.Abort()
+			return
+		}
+		if err := checker.CheckPost(ctx, claims.UserId); err != nil {
+			ginx.Render(ctx, ginx.Result{}, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
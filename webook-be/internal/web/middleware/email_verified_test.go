@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkerFunc func(ctx context.Context, uid int64) error
+
+func (f checkerFunc) CheckPost(ctx context.Context, uid int64) error {
+	return f(ctx, uid)
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	errNotVerified := errs.New(errs.UserEmailNotVerified, "邮箱尚未验证")
+	testCases := []struct {
+		name     string
+		claims   *JWTClaims
+		wantNext bool
+		wantCode errs.Code
+	}{
+		{
+			name:     "已经验证",
+			claims:   &JWTClaims{UserId: 123},
+			wantNext: true,
+		},
+		{
+			name:     "没有验证",
+			claims:   &JWTClaims{UserId: 456},
+			wantCode: errs.UserEmailNotVerified,
+		},
+		{
+			name:     "没有登录信息",
+			wantCode: errs.InternalServerError,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			checker := checkerFunc(func(ctx context.Context, uid int64) error {
+				if uid == 456 {
+					return errNotVerified
+				}
+				return nil
+			})
+			next := false
+			server := gin.New()
+			server.Use(func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set(ginx.ClaimsKey, tc.claims)
+				}
+			})
+			server.POST("/post", RequireVerifiedEmail(checker), func(ctx *gin.Context) {
+				next = true
+				ctx.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/post", nil))
+			assert.Equal(t, tc.wantNext, next)
+			if !tc.wantNext {
+				assert.Contains(t, recorder.Body.String(), fmt.Sprintf(`"code":%d`, tc.wantCode))
+			}
+		})
+	}
+}
@@ -1,35 +1,58 @@
 package middleware
 
 import (
+	"context"
 	"encoding/gob"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
 )
 
 type LoginJWTMiddleware struct {
-	paths []string
+	paths     []string
+	hdl       ijwt.Handler
+	blacklist *SessionBlacklist
+	recorder  SessionRecorder
 }
 
+// SessionRecorder 记录登录的最后活跃时间
+type SessionRecorder interface {
+	Touch(ctx context.Context, uid int64, ssid string) error
+}
+
+// JWTClaims 短期的 access token
 type JWTClaims struct {
 	jwt.RegisteredClaims
 	UserId    int64
 	UserAgent string
+	// 一次登录对应一个 ssid，同一次登录刷新出来的 token 共用一个 ssid
+	Ssid string
 }
 
-var (
-	JwtSecret = []byte("f2Ug8BsFjZmuUyAVi11ZA2J36Sc0RXwE")
-)
+// RefreshClaims 长期的 refresh token，只能用来换取新的 access token
+type RefreshClaims struct {
+	jwt.RegisteredClaims
+	UserId    int64
+	UserAgent string
+	Ssid      string
+}
 
 const (
-	JWTExpire = time.Minute * 5
+	JWTExpire     = time.Minute * 30
+	RefreshExpire = time.Hour * 24 * 7
 )
 
-func NewJWTLoginMiddleware() *LoginJWTMiddleware {
-	return &LoginJWTMiddleware{}
+func NewJWTLoginMiddleware(hdl ijwt.Handler, blacklist *SessionBlacklist) *LoginJWTMiddleware {
+	return &LoginJWTMiddleware{
+		hdl:       hdl,
+		blacklist: blacklist,
+	}
 }
 
 func (l *LoginJWTMiddleware) AddIgnorePaths(paths []string) *LoginJWTMiddleware {
@@ -41,50 +64,72 @@ func (l *LoginJWTMiddleware) AddIgnorePath(path ...string) *LoginJWTMiddleware {
 	return l
 }
 
+// RecordSession 每次请求通过校验后，通过 recorder 更新登录的最后活跃时间
+func (l *LoginJWTMiddleware) RecordSession(recorder SessionRecorder) *LoginJWTMiddleware {
+	l.recorder = recorder
+	return l
+}
+
 func (l *LoginJWTMiddleware) Build() gin.HandlerFunc {
 	// 注册time.Now()类型，让cookie支持存储时间数据
 	gob.Register(time.Now())
 	return func(ctx *gin.Context) {
 		for _, path := range l.paths {
-			if ctx.Request.URL.Path == path {
+			// 以 * 结尾时按前缀匹配，如 /static/*
+			if prefix, ok := strings.CutSuffix(path, "*"); ok {
+				if strings.HasPrefix(ctx.Request.URL.Path, prefix) {
+					return
+				}
+			} else if ctx.Request.URL.Path == path {
 				return
 			}
 		}
-		tokenHeader := ctx.GetHeader("Authorization")
-		if tokenHeader == "" {
+		tokenStr := ExtractToken(ctx)
+		if tokenStr == "" {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		tokenParts := strings.Split(tokenHeader, " ")
-		if len(tokenParts) != 2 {
+		claims := &JWTClaims{}
+		err := l.hdl.ParseAccessToken(tokenStr, claims)
+		if err != nil || claims.UserId == 0 || claims.Ssid == "" {
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		tokenStr := tokenParts[1]
-		claims := &JWTClaims{}
-		token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-			return JwtSecret, nil
-		})
-		if err != nil {
+		if claims.UserAgent != ctx.Request.UserAgent() {
+			// ua异常
+			// 可能是JWT泄露或浏览器更新
 			ctx.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
-		if token == nil || !token.Valid || claims.UserId == 0 {
-			ctx.AbortWithStatus(http.StatusUnauthorized)
+		revoked, err := l.blacklist.Contains(ctx, claims.Ssid)
+		if err != nil {
+			slog.Error("查询登录状态失败", slog.Int64("uid", claims.UserId), slog.Any("err", err))
+			ctx.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
-		if claims.UserAgent != ctx.Request.UserAgent() {
-			// ua异常
-			// 可能是JWT泄露或浏览器更新
+		if revoked {
+			// 已经退出登录
 			ctx.AbortWithStatus(http.StatusUnauthorized)
+			return
 		}
-		if time.Until(claims.ExpiresAt.Time) < (JWTExpire / 2) {
-			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(JWTExpire))
-			token = jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-			tokenStr, _ := token.SignedString(JwtSecret)
-			ctx.Header("X-JWT-Token", tokenStr)
+		if l.recorder != nil {
+			if err = l.recorder.Touch(ctx, claims.UserId, claims.Ssid); err != nil {
+				// 只影响登录列表的展示，不拦截请求
+				slog.Error("更新登录活跃时间失败", slog.Int64("uid", claims.UserId), slog.Any("err", err))
+			}
 		}
-		ctx.Set("claims", claims)
+		// access token 过期后由前端调用 /users/refresh_token 换取新的 token，这里不再续期
+		ctx.Set(ginx.ClaimsKey, claims)
 		ctx.Set("userId", claims.UserId)
 	}
 }
+
+// ExtractToken 从 Authorization 头中取出 token，格式为 Bearer xxx
+func ExtractToken(ctx *gin.Context) string {
+	tokenHeader := ctx.GetHeader("Authorization")
+	tokenParts := strings.Split(tokenHeader, " ")
+	if len(tokenParts) != 2 {
+		return ""
+	}
+	return tokenParts[1]
+}
@@ -0,0 +1,5 @@
+package middleware
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(NewSessionBlacklist)
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionBlacklist 记录已经退出登录的 ssid
+// ssid 在黑名单中保留到 refresh token 过期为止，之后 token 自然失效
+type SessionBlacklist struct {
+	cmd redis.Cmdable
+}
+
+func NewSessionBlacklist(cmd redis.Cmdable) *SessionBlacklist {
+	return &SessionBlacklist{
+		cmd: cmd,
+	}
+}
+
+func (b *SessionBlacklist) Add(ctx context.Context, ssid string) error {
+	return b.cmd.Set(ctx, b.key(ssid), "", RefreshExpire).Err()
+}
+
+func (b *SessionBlacklist) Contains(ctx context.Context, ssid string) (bool, error) {
+	cnt, err := b.cmd.Exists(ctx, b.key(ssid)).Result()
+	return cnt > 0, err
+}
+
+func (b *SessionBlacklist) key(ssid string) string {
+	return fmt.Sprintf("users:ssid:%s", ssid)
+}
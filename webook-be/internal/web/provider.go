@@ -0,0 +1,5 @@
+package web
+
+import "github.com/google/wire"
+
+var ProviderSet = wire.NewSet(NewUserHandler, NewJWKSHandler)
@@ -0,0 +1,8 @@
+package web
+
+import "github.com/newton-miku/webook/webook-be/pkg/errs"
+
+// invalidParam 参数格式有误，msg 为展示给用户的原因
+func invalidParam(msg string) error {
+	return errs.New(errs.InvalidParam, msg)
+}
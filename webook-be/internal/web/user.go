@@ -1,310 +1,521 @@
 package web
 
 import (
+	"crypto/rand"
 	"errors"
-	"fmt"
+	"log/slog"
 	"net/http"
-	"time"
 
-	"github.com/dlclark/regexp2"
-	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
-	jwt "github.com/golang-jwt/jwt/v5"
 	"github.com/newton-miku/webook/webook-be/internal/domain"
 	"github.com/newton-miku/webook/webook-be/internal/service"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
 	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
 )
 
+const (
+	bizLogin     = "login"
+	bizBindPhone = "bind_phone"
+)
+
+// 上传头像的请求体上限，头像本身的大小由 AvatarService 校验
+const maxAvatarRequestBytes = 10 << 20
+
 type UserHandler struct {
-	svc         *service.UserService
-	EmailReg    *regexp2.Regexp
-	PasswordReg *regexp2.Regexp
-	DateReg     *regexp2.Regexp
-}
-
-func NewUserHandler(svc *service.UserService) *UserHandler {
-	const (
-		// 邮箱正则（邮箱用户名部分，可以包含字母、数字、点、下划线、百分号、加号和减号）
-		EmailRegPattern = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
-		// EmailRegPattern = `^\w+(-+.\w+)*@\w+(-.\w+)*.\w+(-.\w+)*$`
-		// 密码正则（长度6位以上，包含字母和数字）
-		PasswordRegPattern = `^(?=.*[a-zA-Z])(?=.*\d).{6,}$`
-		// 日期正则
-		DateRegPattern = `^\d{4}-\d{2}-\d{2}$`
-	)
-	emailReg := regexp2.MustCompile(EmailRegPattern, regexp2.None)
-	pwdReg := regexp2.MustCompile(PasswordRegPattern, regexp2.None)
-	dateReg := regexp2.MustCompile(DateRegPattern, regexp2.None)
-	return &UserHandler{
-		svc:         svc,
-		EmailReg:    emailReg,
-		PasswordReg: pwdReg,
-		DateReg:     dateReg,
-	}
+	jwtHandler
+	svc       service.UserService
+	codeSvc   *service.CodeService
+	resetSvc  service.PasswordResetService
+	verifySvc service.EmailVerifyService
+	guard     service.LoginGuard
+	avatarSvc service.AvatarService
 }
 
-type Msg struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
+func NewUserHandler(svc service.UserService, codeSvc *service.CodeService,
+	resetSvc service.PasswordResetService, verifySvc service.EmailVerifyService, guard service.LoginGuard,
+	mfaSvc service.MFAService, avatarSvc service.AvatarService, jwtHdl ijwt.Handler,
+	sessionSvc service.SessionService, blacklist *middleware.SessionBlacklist) *UserHandler {
+	return &UserHandler{
+		jwtHandler: newJWTHandler(jwtHdl, blacklist, sessionSvc, mfaSvc),
+		svc:        svc,
+		codeSvc:    codeSvc,
+		resetSvc:   resetSvc,
+		verifySvc:  verifySvc,
+		guard:      guard,
+		avatarSvc:  avatarSvc,
+	}
 }
 
 func (u *UserHandler) RegisterRoutes(server *gin.Engine) {
-	server.POST("/users/signup", u.SignUp)
-	server.POST("/users/login", u.Login)
-	server.POST("/users/logout", u.Logout)
-	server.GET("/users/profile", u.Profile)
-	server.POST("/users/edit", u.Edit)
+	u.RegisterRoutesV1(server.Group("/users"))
 }
+
 func (u *UserHandler) RegisterRoutesV1(ug *gin.RouterGroup) {
-	ug.POST("/signup", u.SignUp)
-	ug.POST("/login", u.Login)
-	ug.POST("/logout", u.Logout)
-	ug.GET("/profile", u.Profile)
-	ug.POST("/edit", u.Edit)
-}
-
-func (u *UserHandler) SignUp(ctx *gin.Context) {
-	type SignUpReq struct {
-		Email           string `json:"email"`
-		Password        string `json:"password"`
-		ConfirmPassword string `json:"confirmPassword"`
-	}
-	var req SignUpReq
-	// Bind 方法会根据请求的 Content-Type 来选择绑定器
-	// 如果绑定失败，会返回 400
-	if err := ctx.Bind(&req); err != nil {
-		return
+	// 昵称、简介和头像会展示给其它用户，emailVerify.require 为 post 时需要先验证邮箱
+	requireVerified := middleware.RequireVerifiedEmail(u.verifySvc)
+	ug.POST("/signup", ginx.WrapBody(u.SignUp))
+	ug.POST("/login", ginx.WrapBody(u.Login))
+	ug.POST("/login/mfa", ginx.WrapBody(u.LoginMFA))
+	ug.POST("/logout", ginx.WrapClaims(u.Logout))
+	ug.POST("/refresh_token", u.RefreshToken)
+	ug.GET("/profile", ginx.WrapClaims(u.Profile))
+	ug.PATCH("/profile", requireVerified, ginx.WrapBodyAndClaims(u.Edit))
+	// 兼容旧的前端
+	ug.POST("/edit", requireVerified, ginx.WrapBodyAndClaims(u.Edit))
+	ug.POST("/avatar", requireVerified, ginx.WrapClaims(u.UploadAvatar))
+	ug.POST("/login_sms/code/send", ginx.WrapBody(u.SendLoginSMSCode))
+	ug.POST("/login_sms", ginx.WrapBody(u.LoginSMS))
+	ug.POST("/bind/phone/code/send", ginx.WrapBody(u.SendBindPhoneCode))
+	ug.POST("/bind/phone", ginx.WrapBodyAndClaims(u.BindPhone))
+	ug.POST("/unbind", ginx.WrapBodyAndClaims(u.Unbind))
+	ug.GET("/sessions", ginx.WrapClaims(u.Sessions))
+	ug.POST("/sessions/revoke", ginx.WrapBodyAndClaims(u.RevokeSessions))
+	ug.POST("/password/change", ginx.WrapBodyAndClaims(u.ChangePassword))
+	ug.POST("/password/reset/send", ginx.WrapBody(u.SendResetPasswordMail))
+	ug.POST("/password/reset", ginx.WrapBody(u.ResetPassword))
+	ug.POST("/verify_email", ginx.WrapBody(u.VerifyEmail))
+	ug.POST("/verify_email/send", ginx.WrapBody(u.SendVerifyEmail))
+	ug.POST("/mfa/enroll", ginx.WrapClaims(u.EnrollMFA))
+	ug.POST("/mfa/activate", ginx.WrapBodyAndClaims(u.ActivateMFA))
+	ug.POST("/mfa/disable", ginx.WrapBodyAndClaims(u.DisableMFA))
+}
+
+// 请求参数的校验规则见 pkg/ginx/validation，校验失败时由 ginx 统一返回所有错误
+// 密码强度由 service.PasswordPolicy 校验
+
+type SignUpReq struct {
+	Email           string `json:"email" binding:"required,email" label:"邮箱"`
+	Password        string `json:"password" binding:"required" label:"密码"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required,eqfield=Password" label:"确认密码"`
+}
+
+func (u *UserHandler) SignUp(ctx *gin.Context, req SignUpReq) (ginx.Result, error) {
+	err := u.svc.SignUp(ctx, domain.User{
+		Email:    req.Email,
+		Password: []byte(req.Password),
+	})
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	// 验证邮件发送失败不影响注册，用户可以重新发送
+	if err = u.verifySvc.SendVerifyMail(ctx, req.Email); err != nil {
+		slog.Error("发送验证邮件失败", slog.Any("err", err))
+	}
+	return ginx.Result{Msg: "注册成功，请查收验证邮件"}, nil
+}
+
+type LoginReq struct {
+	Email    string `json:"email" binding:"required" label:"邮箱"`
+	Password string `json:"password" binding:"required" label:"密码"`
+	// 失败次数过多之后需要人机验证
+	Captcha string `json:"captcha"`
+}
+
+func (u *UserHandler) Login(ctx *gin.Context, req LoginReq) (ginx.Result, error) {
+	ip := ctx.ClientIP()
+	if err := u.guard.Check(ctx, req.Email, ip, req.Captcha); err != nil {
+		return ginx.Result{}, err
+	}
+	user, err := u.svc.Login(ctx, domain.User{
+		Email:    req.Email,
+		Password: []byte(req.Password),
+	})
+	if errors.Is(err, service.ErrInvalidUserOrPassword) {
+		if gerr := u.guard.OnFailure(ctx, req.Email, ip); gerr != nil {
+			slog.Error("记录登录失败次数失败", slog.String("ip", ip), slog.Any("err", gerr))
+		}
+		return ginx.Result{}, err
 	}
-	ok, err := u.EmailReg.MatchString(req.Email)
 	if err != nil {
-		fmt.Println("邮箱校验时系统内部出错,err:", err)
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 500,
-			Msg:  "系统内部出错,请稍后再试",
-		})
-		return
+		return ginx.Result{}, err
 	}
-	if !ok {
-		data := Msg{Code: 400, Msg: "邮箱格式有误"}
-		ctx.JSON(http.StatusOK, data)
-		return
+	if err = u.guard.OnSuccess(ctx, req.Email); err != nil {
+		slog.Error("清空登录失败次数失败", slog.Int64("uid", user.Id), slog.Any("err", err))
 	}
-	if req.Password != req.ConfirmPassword {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "两次输入的密码不一致",
-		})
-		return
+	if err = u.verifySvc.CheckLogin(user); err != nil {
+		return ginx.Result{}, err
 	}
+	return u.login(ctx, user.Id, loginMethodEmail)
+}
 
-	ok, err = u.PasswordReg.MatchString(req.Password)
+type LoginMFAReq struct {
+	// 登录接口返回的 mfaToken
+	MFAToken string `json:"mfaToken" binding:"required" label:"登录凭证"`
+	// 验证器应用中的 6 位验证码，或者恢复码
+	Code string `json:"code" binding:"required" label:"验证码"`
+}
+
+// LoginMFA 开启了两步验证时，第一步登录成功后调用这个接口完成登录
+func (u *UserHandler) LoginMFA(ctx *gin.Context, req LoginMFAReq) (ginx.Result, error) {
+	p, err := u.mfaSvc.CompleteLogin(ctx, req.MFAToken, req.Code)
 	if err != nil {
-		fmt.Println("密码校验时系统内部出错,err:", err)
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 500,
-			Msg:  "系统内部出错,请稍后再试",
-		})
-		return
+		return ginx.Result{}, err
+	}
+	if err = u.setLoginToken(ctx, p.UID, p.Method); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "登录成功"}, nil
+}
+
+type SendSMSCodeReq struct {
+	Phone string `json:"phone" binding:"required,phone" label:"手机号"`
+}
+
+func (u *UserHandler) SendLoginSMSCode(ctx *gin.Context, req SendSMSCodeReq) (ginx.Result, error) {
+	return u.sendSMSCode(ctx, bizLogin, req.Phone)
+}
+
+func (u *UserHandler) SendBindPhoneCode(ctx *gin.Context, req SendSMSCodeReq) (ginx.Result, error) {
+	return u.sendSMSCode(ctx, bizBindPhone, req.Phone)
+}
+
+func (u *UserHandler) sendSMSCode(ctx *gin.Context, biz, phone string) (ginx.Result, error) {
+	if err := u.codeSvc.Send(ctx, biz, phone); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "发送成功"}, nil
+}
+
+// verifyCode 校验短信验证码，验证码不对时也返回错误
+func (u *UserHandler) verifyCode(ctx *gin.Context, biz, phone, code string) error {
+	ok, err := u.codeSvc.Verify(ctx, biz, phone, code)
+	if err != nil {
+		return err
 	}
 	if !ok {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "密码格式有误，至少包含字母、数字，且长度不低于6位",
-		})
-		return
+		return errs.New(errs.CodeInvalid, "验证码有误")
 	}
-	err = u.svc.SignUp(ctx, domain.User{
-		Email:    req.Email,
-		Password: []byte(req.Password),
-	})
+	return nil
+}
+
+type LoginSMSReq struct {
+	Phone string `json:"phone" binding:"required,phone" label:"手机号"`
+	Code  string `json:"code" binding:"required" label:"验证码"`
+}
+
+func (u *UserHandler) LoginSMS(ctx *gin.Context, req LoginSMSReq) (ginx.Result, error) {
+	if err := u.verifyCode(ctx, bizLogin, req.Phone, req.Code); err != nil {
+		return ginx.Result{}, err
+	}
+	user, err := u.svc.FindOrCreateByPhone(ctx, req.Phone)
 	if err != nil {
-		if errors.Is(err, service.ErrUserDuplicateEmail) {
-			ctx.JSON(http.StatusOK, Msg{
-				Code: 500,
-				Msg:  "该邮箱已被注册",
-			})
-		} else {
-			ctx.JSON(http.StatusOK, Msg{
-				Code: 500,
-				Msg:  "注册失败，系统内部错误",
-			})
-			fmt.Println("注册失败,err:", err)
-		}
-		return
+		return ginx.Result{}, err
 	}
+	return u.login(ctx, user.Id, loginMethodSMS)
+}
 
-	ctx.JSON(http.StatusOK, Msg{
-		Code: 0,
-		Msg:  "注册成功",
-	})
-	// fmt.Printf("req: %v\n", req)
+type BindPhoneReq struct {
+	Phone string `json:"phone" binding:"required,phone" label:"手机号"`
+	Code  string `json:"code" binding:"required" label:"验证码"`
+	// 手机号属于另一个账号时，是否把那个账号合并进来
+	Merge bool `json:"merge"`
+}
 
+func (u *UserHandler) BindPhone(ctx *gin.Context, req BindPhoneReq, claims *middleware.JWTClaims) (ginx.Result, error) {
+	if err := u.verifyCode(ctx, bizBindPhone, req.Phone, req.Code); err != nil {
+		return ginx.Result{}, err
+	}
+	// 手机号属于其它账号时返回 errs.UserIdentityBoundToOther
+	// 前端询问用户是否合并账号，确认后带上 merge 重新绑定
+	mergedID, err := u.svc.BindPhone(ctx, claims.UserId, req.Phone, req.Merge)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	if err = u.revokeMerged(ctx, mergedID); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "绑定成功"}, nil
 }
-func (u *UserHandler) Login(ctx *gin.Context) {
-	type LoginReq struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+
+type UnbindReq struct {
+	Type string `json:"type" binding:"required,oneof=phone wechat" label:"解绑类型"`
+}
+
+func (u *UserHandler) Unbind(ctx *gin.Context, req UnbindReq, claims *middleware.JWTClaims) (ginx.Result, error) {
+	var err error
+	switch req.Type {
+	case "phone":
+		err = u.svc.UnbindPhone(ctx, claims.UserId)
+	case "wechat":
+		err = u.svc.UnbindWechat(ctx, claims.UserId)
 	}
-	var req LoginReq
-	if err := ctx.Bind(&req); err != nil {
-		return
+	if err != nil {
+		return ginx.Result{}, err
 	}
-	user, err := u.svc.Login(ctx, domain.User{
-		Email:    req.Email,
-		Password: []byte(req.Password),
-	})
+	return ginx.Result{Msg: "解绑成功"}, nil
+}
+
+// RefreshToken 用 refresh token 换取新的 access token，同时轮换 refresh token
+// 请求时 Authorization 头中携带的是 refresh token
+// refresh token 无效时返回 401，前端据此跳转到登录页，所以没有使用 ginx 的包装
+func (u *UserHandler) RefreshToken(ctx *gin.Context) {
+	claims, err := u.parseRefreshToken(ctx)
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidUserOrPassword) {
-			ctx.JSON(http.StatusOK, Msg{
-				Code: 400,
-				Msg:  "邮箱或者密码不正确",
-			})
-		} else {
-			ctx.JSON(http.StatusOK, Msg{
-				Code: 500,
-				Msg:  "登录时系统发生错误",
-			})
+		ctx.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	// 每次刷新都换一个新的 refresh token，旧的随之失效
+	refreshID := rand.Text()
+	err = u.sessionSvc.RotateRefresh(ctx, claims.Ssid, claims.ID, refreshID)
+	if errors.Is(err, service.ErrRefreshTokenReused) {
+		// 旧的 refresh token 又被用了一次，可能已经泄露，让整次登录失效
+		if err = u.revokeSessions(ctx, claims.UserId, claims.Ssid); err != nil {
+			slog.Error("refresh token 被重复使用，退出登录失败",
+				slog.Int64("uid", claims.UserId), slog.String("ssid", claims.Ssid), slog.Any("err", err))
 		}
+		ctx.AbortWithStatus(http.StatusUnauthorized)
 		return
 	}
-	ua := ctx.Request.UserAgent()
-	if ua == "" {
-		// ua为空或者无ua头
-		ctx.JSON(http.StatusOK, Msg{
-			Code: http.StatusBadRequest,
-			Msg:  "Go Away",
-		})
+	if err != nil {
+		ginx.Render(ctx, ginx.Result{}, err)
 		return
 	}
-	claims := middleware.JWTClaims{
-		UserId:    user.Id,
-		UserAgent: ua,
+	if err = u.sessionSvc.Touch(ctx, claims.UserId, claims.Ssid); err != nil {
+		slog.Error("更新登录活跃时间失败", slog.Int64("uid", claims.UserId), slog.Any("err", err))
+	}
+	// 沿用原来的 ssid，这样同一次登录刷新出来的 token 可以一起失效
+	if err = u.setJWTToken(ctx, claims.UserId, claims.Ssid); err == nil {
+		err = u.setRefreshToken(ctx, claims.UserId, claims.Ssid, refreshID)
 	}
-	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(middleware.JWTExpire))
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-	tokenStr, err := token.SignedString(middleware.JwtSecret)
+	ginx.Render(ctx, ginx.Result{Msg: "刷新成功"}, err)
+}
+
+func (u *UserHandler) Logout(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	if err := u.clearToken(ctx, claims); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "登出成功"}, nil
+}
+
+type SessionVO struct {
+	Ssid      string `json:"ssid"`
+	UserAgent string `json:"userAgent"`
+	IP        string `json:"ip"`
+	Method    string `json:"method"`
+	LoginTime int64  `json:"loginTime"`
+	LastSeen  int64  `json:"lastSeen"`
+	// 是否是发起请求的这次登录
+	Current bool `json:"current"`
+}
+
+// Sessions 列出当前用户所有有效的登录
+func (u *UserHandler) Sessions(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	sessions, err := u.sessionSvc.List(ctx, claims.UserId)
 	if err != nil {
-		fmt.Println("JWT 签名错误,err:", err)
-		ctx.JSON(http.StatusOK, Msg{
-			Code: http.StatusInternalServerError,
-			Msg:  "登录时系统发生错误",
+		return ginx.Result{}, err
+	}
+	vos := make([]SessionVO, 0, len(sessions))
+	for _, s := range sessions {
+		vos = append(vos, SessionVO{
+			Ssid:      s.Ssid,
+			UserAgent: s.UserAgent,
+			IP:        s.IP,
+			Method:    s.Method,
+			LoginTime: s.LoginTime,
+			LastSeen:  s.LastSeen,
+			Current:   s.Ssid == claims.Ssid,
 		})
-		return
 	}
-	ctx.Header("X-JWT-Token", tokenStr)
-	print(user.Id)
-	ctx.JSON(http.StatusOK, Msg{
-		Code: 0,
-		Msg:  "登录成功",
-	})
+	return ginx.Result{Data: vos}, nil
 }
 
-func (u *UserHandler) Logout(ctx *gin.Context) {
-	sess := sessions.Default(ctx)
-	sess.Options(sessions.Options{
-		MaxAge: -1,
-	})
-	sess.Clear()
-	sess.Save()
-	ctx.JSON(http.StatusOK, Msg{
-		Code: 0,
-		Msg:  "登出成功",
-	})
+type RevokeSessionsReq struct {
+	Ssid string `json:"ssid"`
+	// 为 true 时忽略 ssid，踢掉除当前登录以外的所有登录
+	Others bool `json:"others"`
 }
 
-func (u *UserHandler) Profile(ctx *gin.Context) {
-	c, _ := ctx.Get("claims")
-	claims, ok := c.(*middleware.JWTClaims)
-	if !ok {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: http.StatusInternalServerError,
-			Msg:  "内部错误",
-		})
+// RevokeSessions 让指定的登录失效，或者让除当前登录以外的所有登录失效
+func (u *UserHandler) RevokeSessions(ctx *gin.Context, req RevokeSessionsReq,
+	claims *middleware.JWTClaims) (ginx.Result, error) {
+	if !req.Others && req.Ssid == "" {
+		return ginx.Result{}, invalidParam("请指定要退出的登录")
 	}
-	id := claims.UserId
-	user, err := u.svc.Profile(ctx.Request.Context(), id)
+	sessions, err := u.sessionSvc.List(ctx, claims.UserId)
 	if err != nil {
-		if errors.Is(err, service.ErrProfileNotFound) {
+		return ginx.Result{}, err
+	}
+	// 只能在当前用户自己的登录里挑选，避免踢掉别人的登录
+	var ssids []string
+	for _, s := range sessions {
+		if (req.Others && s.Ssid != claims.Ssid) || (!req.Others && s.Ssid == req.Ssid) {
+			ssids = append(ssids, s.Ssid)
 		}
 	}
-	ctx.JSON(http.StatusOK, user)
+	if !req.Others && len(ssids) == 0 {
+		return ginx.Result{}, errs.New(errs.UserSessionNotFound, "登录不存在或已失效")
+	}
+	if err = u.revokeSessions(ctx, claims.UserId, ssids...); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "已退出登录"}, nil
+}
+
+type ChangePasswordReq struct {
+	OldPassword     string `json:"oldPassword" binding:"required" label:"原密码"`
+	NewPassword     string `json:"newPassword" binding:"required" label:"新密码"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required,eqfield=NewPassword" label:"确认密码"`
 }
-func (u *UserHandler) Edit(ctx *gin.Context) {
-	type EditReq struct {
-		Birthday string `json:"birthday"`
-		Nickname string `json:"nickname"`
-		Summary  string `json:"aboutMe"`
+
+// ChangePassword 修改密码后，除当前登录以外的所有登录都会失效
+func (u *UserHandler) ChangePassword(ctx *gin.Context, req ChangePasswordReq,
+	claims *middleware.JWTClaims) (ginx.Result, error) {
+	if err := u.svc.ChangePassword(ctx, claims.UserId, req.OldPassword, req.NewPassword); err != nil {
+		return ginx.Result{}, err
 	}
-	var req EditReq
-	if err := ctx.Bind(&req); err != nil {
-		return
+	if err := u.revokeAllSessions(ctx, claims.UserId, claims.Ssid); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "密码修改成功"}, nil
+}
+
+type SendResetPasswordMailReq struct {
+	Email string `json:"email" binding:"required,email" label:"邮箱"`
+}
+
+// SendResetPasswordMail 邮箱是否注册过都返回同样的结果
+func (u *UserHandler) SendResetPasswordMail(ctx *gin.Context, req SendResetPasswordMailReq) (ginx.Result, error) {
+	if err := u.resetSvc.SendResetMail(ctx, req.Email); err != nil {
+		return ginx.Result{}, err
 	}
-	// 校验日期格式是否为2023-05-05
-	ok, err := u.DateReg.MatchString(req.Birthday)
+	return ginx.Result{Msg: "如果该邮箱已注册，你将收到一封重置密码的邮件"}, nil
+}
+
+type ResetPasswordReq struct {
+	// 重置邮件链接中的 token
+	Token           string `json:"token" binding:"required" label:"重置链接"`
+	NewPassword     string `json:"newPassword" binding:"required" label:"新密码"`
+	ConfirmPassword string `json:"confirmPassword" binding:"required,eqfield=NewPassword" label:"确认密码"`
+}
+
+// ResetPassword 重置密码后，这个用户所有的登录都会失效
+func (u *UserHandler) ResetPassword(ctx *gin.Context, req ResetPasswordReq) (ginx.Result, error) {
+	uid, err := u.resetSvc.Reset(ctx, req.Token, req.NewPassword)
 	if err != nil {
-		fmt.Println("生日格式校验时系统内部出错,err:", err)
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 500,
-			Msg:  "系统内部出错,请稍后再试",
-		})
-		return
+		return ginx.Result{}, err
 	}
-	if !ok {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "生日格式有误",
-		})
-		return
+	if err = u.revokeAllSessions(ctx, uid, ""); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "密码已重置，请重新登录"}, nil
+}
+
+type VerifyEmailReq struct {
+	// 验证邮件链接中的 token
+	Token string `json:"token" binding:"required" label:"验证链接"`
+}
+
+func (u *UserHandler) VerifyEmail(ctx *gin.Context, req VerifyEmailReq) (ginx.Result, error) {
+	if err := u.verifySvc.Verify(ctx, req.Token); err != nil {
+		return ginx.Result{}, err
 	}
-	// 将字符串转换为 time.Time 类型进行日期比较
-	reqBirthday, err := time.Parse("2006-01-02", req.Birthday)
+	return ginx.Result{Msg: "邮箱验证成功"}, nil
+}
+
+type SendVerifyEmailReq struct {
+	Email string `json:"email" binding:"required,email" label:"邮箱"`
+}
+
+// SendVerifyEmail 重新发送验证邮件，不需要登录，因为未验证时可能无法登录
+func (u *UserHandler) SendVerifyEmail(ctx *gin.Context, req SendVerifyEmailReq) (ginx.Result, error) {
+	if err := u.verifySvc.SendVerifyMail(ctx, req.Email); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "如果该邮箱已注册且未验证，你将收到一封验证邮件"}, nil
+}
+
+type MFAEnrollVO struct {
+	// 无法扫码时手动输入的密钥
+	Secret string `json:"secret"`
+	// otpauth:// 地址，前端生成二维码
+	URI string `json:"uri"`
+}
+
+// EnrollMFA 获取新的两步验证密钥，激活之前不会生效，重复调用时之前的密钥作废
+func (u *UserHandler) EnrollMFA(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	e, err := u.mfaSvc.Enroll(ctx, claims.UserId)
 	if err != nil {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "生日解析失败",
-		})
-		return
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Data: MFAEnrollVO{Secret: e.Secret, URI: e.URI}}, nil
+}
 
-	// 获取当前时间并校验请求中的日期是否超过当前时间
-	now := time.Now()
-	if reqBirthday.After(now) {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "生日不能超过当前日期",
-		})
-		return
+type MFACodeReq struct {
+	Code string `json:"code" binding:"required" label:"验证码"`
+}
+
+// ActivateMFA 验证第一个验证码，开启两步验证，返回的恢复码只显示这一次
+func (u *UserHandler) ActivateMFA(ctx *gin.Context, req MFACodeReq, claims *middleware.JWTClaims) (ginx.Result, error) {
+	codes, err := u.mfaSvc.Activate(ctx, claims.UserId, req.Code)
+	if err != nil {
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Msg: "两步验证已开启，请妥善保存恢复码", Data: codes}, nil
+}
 
-	// 设置允许的最小日期，例如：1900-01-01
-	minDate := time.Date(1900, 1, 1, 0, 0, 0, 0, time.Now().Location())
-	if reqBirthday.Before(minDate) {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: 400,
-			Msg:  "生日不能早于1900年1月1日",
-		})
-		return
+// DisableMFA 关闭两步验证，需要验证码或者恢复码
+func (u *UserHandler) DisableMFA(ctx *gin.Context, req MFACodeReq, claims *middleware.JWTClaims) (ginx.Result, error) {
+	if err := u.mfaSvc.Disable(ctx, claims.UserId, req.Code); err != nil {
+		return ginx.Result{}, err
 	}
+	return ginx.Result{Msg: "两步验证已关闭"}, nil
+}
 
-	c, _ := ctx.Get("claims")
-	claims, ok := c.(*middleware.JWTClaims)
-	if !ok {
-		ctx.JSON(http.StatusOK, Msg{
-			Code: http.StatusInternalServerError,
-			Msg:  "内部错误",
-		})
+func (u *UserHandler) Profile(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	user, err := u.svc.Profile(ctx, claims.UserId)
+	if err != nil {
+		return ginx.Result{}, err
 	}
-	id := claims.UserId
-	err = u.svc.UpdateProfile(ctx, domain.UserProfile{
-		UID:      id,
+	return ginx.Result{Data: user}, nil
+}
+
+// EditReq 请求中没有的字段不修改
+type EditReq struct {
+	// 读取档案时拿到的 version，档案已经被修改过时返回冲突
+	Version int64 `json:"version" binding:"required" label:"版本"`
+	// 格式为 2006-01-02，在 1900-01-01 到今天之间，空字符串表示清空
+	Birthday *string `json:"birthday" binding:"omitzero,date,birthday" label:"生日"`
+	Nickname *string `json:"nickname" binding:"omitempty,max=32" label:"昵称"`
+	Summary  *string `json:"aboutMe"`
+}
+
+// Edit 返回修改后的档案，前端用新的 version 继续修改
+func (u *UserHandler) Edit(ctx *gin.Context, req EditReq, claims *middleware.JWTClaims) (ginx.Result, error) {
+	profile, err := u.svc.UpdateProfile(ctx, domain.UserProfilePatch{
+		UID:      claims.UserId,
+		Version:  req.Version,
 		Birthday: req.Birthday,
 		Nickname: req.Nickname,
 		Summary:  req.Summary,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusOK, fmt.Sprint(err))
-		return
+		return ginx.Result{}, err
 	}
-	ctx.JSON(http.StatusOK, Msg{Code: 0, Msg: "更新成功"})
+	return ginx.Result{Msg: "更新成功", Data: profile}, nil
+}
 
+type AvatarVO struct {
+	AvatarURL string `json:"avatarUrl"`
+}
+
+// UploadAvatar multipart 表单，图片放在 avatar 字段中
+func (u *UserHandler) UploadAvatar(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxAvatarRequestBytes)
+	fh, err := ctx.FormFile("avatar")
+	if err != nil {
+		slog.Warn("读取头像失败", slog.Int64("uid", claims.UserId), slog.Any("err", err))
+		return ginx.Result{}, invalidParam("请选择头像图片，大小不能超过 10MB")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	defer f.Close()
+	avatarURL, err := u.avatarSvc.Upload(ctx, claims.UserId, f)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Msg: "头像已更新", Data: AvatarVO{AvatarURL: avatarURL}}, nil
 }
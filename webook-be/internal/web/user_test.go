@@ -1,9 +1,26 @@
 package web_test
 
 import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/newton-miku/webook/webook-be/internal/domain"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	svcmocks "github.com/newton-miku/webook/webook-be/internal/service/mocks"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,3 +35,636 @@ func TestEncrypt(t *testing.T) {
 	err = bcrypt.CompareHashAndPassword(encrypt, []byte(password))
 	assert.NoError(t, err)
 }
+
+func TestUserHandler_SignUp(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) service.UserService
+		reqBody string
+		// Accept-Language
+		lang     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "注册成功",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), domain.User{
+					Email:    "123@qq.com",
+					Password: []byte("hello#world123"),
+				}).Return(nil)
+				return svc
+			},
+			reqBody:  `{"email":"123@qq.com","password":"hello#world123","confirmPassword":"hello#world123"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"注册成功，请查收验证邮件","data":null}`,
+		},
+		{
+			name:     "参数不对，bind 失败",
+			reqBody:  `{"email":"123@qq.com",`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "邮箱格式不对",
+			reqBody:  `{"email":"123@q","password":"hello#world123","confirmPassword":"hello#world123"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"邮箱格式有误","data":[{"field":"email","msg":"邮箱格式有误"}]}`,
+		},
+		{
+			name:     "两次输入的密码不一致",
+			reqBody:  `{"email":"123@qq.com","password":"hello#world123","confirmPassword":"hello#world1234"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"确认密码和密码不一致","data":[{"field":"confirmPassword","msg":"确认密码和密码不一致"}]}`,
+		},
+		{
+			name: "密码强度不够",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).
+					Return(errs.New(errs.UserWeakPassword, "密码长度不能少于8位，必须包含数字"))
+				return svc
+			},
+			reqBody:  `{"email":"123@qq.com","password":"hello","confirmPassword":"hello"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40110,"msg":"密码长度不能少于8位，必须包含数字","data":null}`,
+		},
+		{
+			name:     "一次返回所有错误",
+			reqBody:  `{"email":"123@q"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"邮箱格式有误；密码不能为空；确认密码不能为空","data":[
+				{"field":"email","msg":"邮箱格式有误"},
+				{"field":"password","msg":"密码不能为空"},
+				{"field":"confirmPassword","msg":"确认密码不能为空"}]}`,
+		},
+		{
+			name:     "英文提示",
+			reqBody:  `{"email":"123@q","password":"hello#world123","confirmPassword":"hello#world1234"}`,
+			lang:     "en-US,en;q=0.9",
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"email must be a valid email address；confirmPassword must match password","data":[
+				{"field":"email","msg":"email must be a valid email address"},
+				{"field":"confirmPassword","msg":"confirmPassword must match password"}]}`,
+		},
+		{
+			name: "邮箱已被注册",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(service.ErrUserDuplicateEmail)
+				return svc
+			},
+			reqBody:  `{"email":"123@qq.com","password":"hello#world123","confirmPassword":"hello#world123"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40101,"msg":"该邮箱已被注册","data":null}`,
+		},
+		{
+			name: "系统异常",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().SignUp(gomock.Any(), gomock.Any()).Return(errors.New("随便一个错误"))
+				return svc
+			},
+			reqBody:  `{"email":"123@qq.com","password":"hello#world123","confirmPassword":"hello#world123"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			var userSvc service.UserService
+			if tc.mock != nil {
+				userSvc = tc.mock(ctrl)
+			}
+			server := newUserServer(t, userSvc, nil, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/signup", bytes.NewBufferString(tc.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tc.lang)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_Login(t *testing.T) {
+	const ua = "Mozilla/5.0"
+	testCases := []struct {
+		name      string
+		mock      func(ctrl *gomock.Controller) (service.UserService, service.SessionService)
+		reqBody   string
+		userAgent string
+		wantCode  int
+		wantBody  string
+		// 是否签发了 token
+		wantToken bool
+	}{
+		{
+			name: "登录成功",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), domain.User{
+					Email:    "123@qq.com",
+					Password: []byte("hello#world123"),
+				}).Return(domain.User{Id: 123}, nil)
+				sessionSvc := svcmocks.NewMockSessionService(ctrl)
+				sessionSvc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, s domain.Session) error {
+						assert.Equal(t, int64(123), s.Uid)
+						assert.Equal(t, "email", s.Method)
+						assert.NotEmpty(t, s.Ssid)
+						return nil
+					})
+				return userSvc, sessionSvc
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":0,"msg":"登录成功","data":null}`,
+			wantToken: true,
+		},
+		{
+			name:     "参数不对，bind 失败",
+			reqBody:  `{"email":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "邮箱或者密码不正确",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{}, service.ErrInvalidUserOrPassword)
+				return userSvc, nil
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":40102,"msg":"邮箱或者密码不正确","data":null}`,
+		},
+		{
+			name: "邮箱未验证",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123, Email: "123@qq.com"}, nil)
+				return userSvc, nil
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":40113,"msg":"邮箱尚未验证，请先点击验证邮件中的链接","data":null}`,
+		},
+		{
+			name: "需要两步验证",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 456}, nil)
+				return userSvc, nil
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":40117,"msg":"请输入两步验证码","data":{"mfaToken":"mfa-token"}}`,
+		},
+		{
+			name: "系统异常",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{}, errors.New("随便一个错误"))
+				return userSvc, nil
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+		{
+			name: "没有 User-Agent",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123}, nil)
+				return userSvc, nil
+			},
+			reqBody:  `{"email":"123@qq.com","password":"hello#world123"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"Go Away","data":null}`,
+		},
+		{
+			name: "记录登录失败",
+			mock: func(ctrl *gomock.Controller) (service.UserService, service.SessionService) {
+				userSvc := svcmocks.NewMockUserService(ctrl)
+				userSvc.EXPECT().Login(gomock.Any(), gomock.Any()).
+					Return(domain.User{Id: 123}, nil)
+				sessionSvc := svcmocks.NewMockSessionService(ctrl)
+				sessionSvc.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(errors.New("redis 错误"))
+				return userSvc, sessionSvc
+			},
+			reqBody:   `{"email":"123@qq.com","password":"hello#world123"}`,
+			userAgent: ua,
+			wantCode:  http.StatusOK,
+			wantBody:  `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			var (
+				userSvc    service.UserService
+				sessionSvc service.SessionService
+			)
+			if tc.mock != nil {
+				userSvc, sessionSvc = tc.mock(ctrl)
+			}
+			server := newUserServer(t, userSvc, sessionSvc, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/login", bytes.NewBufferString(tc.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", tc.userAgent)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			}
+			assert.Equal(t, tc.wantToken, resp.Header().Get("X-JWT-Token") != "")
+			assert.Equal(t, tc.wantToken, resp.Header().Get("X-Refresh-Token") != "")
+		})
+	}
+}
+
+func TestUserHandler_Edit(t *testing.T) {
+	str := func(s string) *string { return &s }
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) service.UserService
+		claims   *middleware.JWTClaims
+		reqBody  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "更新成功",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().UpdateProfile(gomock.Any(), domain.UserProfilePatch{
+					UID:      123,
+					Version:  1,
+					Birthday: str("2000-01-01"),
+					Nickname: str("miku"),
+					Summary:  str("hello"),
+				}).Return(domain.UserProfile{
+					Id: 1, UID: 123, Birthday: "2000-01-01", Nickname: "miku", Summary: "hello",
+					Version: 2, Utime: 1700000000,
+				}, nil)
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"2000-01-01","nickname":"miku","aboutMe":"hello"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"更新成功","data":{"id":1,"uid":123,"email":"","birthday":"2000-01-01",
+				"nickname":"miku","phone":"","aboutMe":"hello","avatarUrl":"","version":2,"utime":1700000000}}`,
+		},
+		{
+			name: "只修改昵称",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().UpdateProfile(gomock.Any(), domain.UserProfilePatch{
+					UID:      123,
+					Version:  1,
+					Nickname: str("miku"),
+				}).Return(domain.UserProfile{UID: 123, Nickname: "miku", Version: 2}, nil)
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"nickname":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"更新成功","data":{"id":0,"uid":123,"email":"","birthday":"",
+				"nickname":"miku","phone":"","aboutMe":"","avatarUrl":"","version":2,"utime":0}}`,
+		},
+		{
+			name: "清空生日",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().UpdateProfile(gomock.Any(), domain.UserProfilePatch{
+					UID:      123,
+					Version:  1,
+					Birthday: str(""),
+				}).Return(domain.UserProfile{UID: 123, Version: 2}, nil)
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":""}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"更新成功","data":{"id":0,"uid":123,"email":"","birthday":"",
+				"nickname":"","phone":"","aboutMe":"","avatarUrl":"","version":2,"utime":0}}`,
+		},
+		{
+			name:     "没有版本号",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"nickname":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"版本不能为空","data":[{"field":"version","msg":"版本不能为空"}]}`,
+		},
+		{
+			name: "档案已被其它请求修改",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).
+					Return(domain.UserProfile{}, service.ErrProfileConflict)
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"nickname":"miku"}`,
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":40123,"msg":"档案已在其它地方修改，请刷新后重试","data":null}`,
+		},
+		{
+			name:     "参数不对，bind 失败",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"birthday":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "生日格式不对",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"2000/01/01"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"生日格式有误，应为 YYYY-MM-DD","data":[{"field":"birthday","msg":"生日格式有误，应为 YYYY-MM-DD"}]}`,
+		},
+		{
+			name:     "生日不是合法日期",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"2000-13-45"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"生日格式有误，应为 YYYY-MM-DD","data":[{"field":"birthday","msg":"生日格式有误，应为 YYYY-MM-DD"}]}`,
+		},
+		{
+			name:     "生日晚于当前日期",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"9999-01-01"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"生日必须在1900年1月1日到今天之间","data":[{"field":"birthday","msg":"生日必须在1900年1月1日到今天之间"}]}`,
+		},
+		{
+			name:     "生日早于 1900 年",
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"1899-12-31"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"生日必须在1900年1月1日到今天之间","data":[{"field":"birthday","msg":"生日必须在1900年1月1日到今天之间"}]}`,
+		},
+		{
+			name:     "没有登录信息",
+			reqBody:  `{"version":1,"birthday":"2000-01-01"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+		{
+			name: "更新失败",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().UpdateProfile(gomock.Any(), gomock.Any()).Return(domain.UserProfile{}, errors.New("随便一个错误"))
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			reqBody:  `{"version":1,"birthday":"2000-01-01"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			var userSvc service.UserService
+			if tc.mock != nil {
+				userSvc = tc.mock(ctrl)
+			}
+			server := newUserServer(t, userSvc, nil, tc.claims)
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewBufferString(tc.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_Profile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) service.UserService
+		claims   *middleware.JWTClaims
+		wantBody string
+	}{
+		{
+			name: "查询成功",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().Profile(gomock.Any(), int64(123)).Return(domain.UserProfile{
+					Id:       1,
+					UID:      123,
+					Email:    "123@qq.com",
+					Birthday: "2000-01-01",
+					Nickname: "miku",
+					Summary:  "hello",
+					Version:  3,
+					Utime:    1700000000,
+				}, nil)
+				return svc
+			},
+			claims: &middleware.JWTClaims{UserId: 123},
+			wantBody: `{"code":0,"msg":"","data":{"id":1,"uid":123,"email":"123@qq.com",
+				"birthday":"2000-01-01","nickname":"miku","phone":"","aboutMe":"hello","avatarUrl":"",
+				"version":3,"utime":1700000000}}`,
+		},
+		{
+			name:     "没有登录信息",
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+		{
+			name: "用户不存在",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().Profile(gomock.Any(), int64(123)).
+					Return(domain.UserProfile{}, service.ErrProfileNotFound)
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			wantBody: `{"code":40103,"msg":"用户不存在","data":null}`,
+		},
+		{
+			name: "查询失败",
+			mock: func(ctrl *gomock.Controller) service.UserService {
+				svc := svcmocks.NewMockUserService(ctrl)
+				svc.EXPECT().Profile(gomock.Any(), int64(123)).
+					Return(domain.UserProfile{}, errors.New("随便一个错误"))
+				return svc
+			},
+			claims:   &middleware.JWTClaims{UserId: 123},
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			var userSvc service.UserService
+			if tc.mock != nil {
+				userSvc = tc.mock(ctrl)
+			}
+			server := newUserServer(t, userSvc, nil, tc.claims)
+
+			req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.JSONEq(t, tc.wantBody, resp.Body.String())
+		})
+	}
+}
+
+func TestUserHandler_RefreshToken(t *testing.T) {
+	const ua = "test-agent"
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller, redisMock redismock.ClientMock) service.SessionService
+		// 请求中 refresh token 的 jti
+		refreshID string
+		wantCode  int
+		wantToken bool
+	}{
+		{
+			name: "刷新成功，换成新的 refresh token",
+			mock: func(ctrl *gomock.Controller, redisMock redismock.ClientMock) service.SessionService {
+				redisMock.ExpectExists("users:ssid:ssid-1").SetVal(0)
+				sessionSvc := svcmocks.NewMockSessionService(ctrl)
+				sessionSvc.EXPECT().RotateRefresh(gomock.Any(), "ssid-1", "refresh-1", gomock.Any()).
+					DoAndReturn(func(_ any, _, _, newID string) error {
+						assert.NotEqual(t, "refresh-1", newID)
+						return nil
+					})
+				sessionSvc.EXPECT().Touch(gomock.Any(), int64(123), "ssid-1").Return(nil)
+				return sessionSvc
+			},
+			refreshID: "refresh-1",
+			wantCode:  http.StatusOK,
+			wantToken: true,
+		},
+		{
+			name: "旧的 refresh token 被重复使用，整次登录失效",
+			mock: func(ctrl *gomock.Controller, redisMock redismock.ClientMock) service.SessionService {
+				redisMock.ExpectExists("users:ssid:ssid-1").SetVal(0)
+				redisMock.ExpectSet("users:ssid:ssid-1", "", middleware.RefreshExpire).SetVal("OK")
+				sessionSvc := svcmocks.NewMockSessionService(ctrl)
+				sessionSvc.EXPECT().RotateRefresh(gomock.Any(), "ssid-1", "refresh-0", gomock.Any()).
+					Return(service.ErrRefreshTokenReused)
+				sessionSvc.EXPECT().Remove(gomock.Any(), int64(123), "ssid-1").Return(nil)
+				return sessionSvc
+			},
+			refreshID: "refresh-0",
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name: "没有 jti 的旧 refresh token",
+			mock: func(ctrl *gomock.Controller, redisMock redismock.ClientMock) service.SessionService {
+				return svcmocks.NewMockSessionService(ctrl)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client, redisMock := redismock.NewClientMock()
+			sessionSvc := tc.mock(ctrl, redisMock)
+			server := newUserServerWithBlacklist(t, nil, sessionSvc, middleware.NewSessionBlacklist(client), nil)
+
+			claims := middleware.RefreshClaims{UserId: 123, UserAgent: ua, Ssid: "ssid-1"}
+			claims.ID = tc.refreshID
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+			token, err := ijwt.NewHandler(nil, newRefreshKeySet(t)).SignRefreshToken(claims)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/refresh_token", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("User-Agent", ua)
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantCode, resp.Code)
+			assert.Equal(t, tc.wantToken, resp.Header().Get("X-Refresh-Token") != "")
+			assert.NoError(t, redisMock.ExpectationsWereMet())
+		})
+	}
+}
+
+// newUserServer claims 不为 nil 时模拟登录校验通过
+func newUserServer(t *testing.T, userSvc service.UserService, sessionSvc service.SessionService,
+	claims *middleware.JWTClaims) *gin.Engine {
+	return newUserServerWithBlacklist(t, userSvc, sessionSvc, nil, claims)
+}
+
+// newRefreshKeySet 测试中签发和校验 refresh token 使用同一个密钥
+func newRefreshKeySet(t *testing.T) *ijwt.KeySet {
+	refreshKey := ijwt.NewHMACKey("refresh", []byte("refresh-secret"))
+	refresh, err := ijwt.NewKeySet(refreshKey.Kid, refreshKey)
+	require.NoError(t, err)
+	return refresh
+}
+
+func newUserServerWithBlacklist(t *testing.T, userSvc service.UserService, sessionSvc service.SessionService,
+	blacklist *middleware.SessionBlacklist, claims *middleware.JWTClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	accessKey, err := ijwt.GenerateEd25519Key("test")
+	require.NoError(t, err)
+	access, err := ijwt.NewKeySet(accessKey.Kid, accessKey)
+	require.NoError(t, err)
+	refresh := newRefreshKeySet(t)
+
+	// 按 login 模式检查邮箱是否验证
+	verifySvc := svcmocks.NewMockEmailVerifyService(gomock.NewController(t))
+	verifySvc.EXPECT().SendVerifyMail(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	verifySvc.EXPECT().CheckLogin(gomock.Any()).DoAndReturn(func(u domain.User) error {
+		if u.Email != "" && !u.EmailVerified {
+			return service.ErrEmailNotVerified
+		}
+		return nil
+	}).AnyTimes()
+	verifySvc.EXPECT().CheckPost(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// 用户 456 开启了两步验证
+	mfaSvc := svcmocks.NewMockMFAService(gomock.NewController(t))
+	mfaSvc.EXPECT().BeginLogin(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, uid int64, method string) (string, error) {
+			if uid == 456 {
+				return "mfa-token", nil
+			}
+			return "", nil
+		}).AnyTimes()
+
+	hdl := web.NewUserHandler(userSvc, nil, nil, verifySvc, service.NewNopLoginGuard(), mfaSvc, nil,
+		ijwt.NewHandler(access, refresh), sessionSvc, blacklist)
+	server := gin.New()
+	server.Use(func(ctx *gin.Context) {
+		if claims != nil {
+			ctx.Set("claims", claims)
+		}
+	})
+	hdl.RegisterRoutesV1(server.Group("/users"))
+	return server
+}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/oauth2/wechat"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx"
+)
+
+const (
+	stateCookieName = "jwt-state"
+	// state 的有效期，用户需要在这段时间内完成扫码
+	stateExpire = 10 * time.Minute
+)
+
+var errStateMismatch = errors.New("state 不匹配")
+
+type OAuth2WechatHandler struct {
+	jwtHandler
+	svc     wechat.Service
+	userSvc service.UserService
+	// 签名 state 使用的密钥
+	stateKey []byte
+}
+
+func NewOAuth2WechatHandler(svc wechat.Service, userSvc service.UserService, stateKey []byte,
+	mfaSvc service.MFAService, jwtHdl ijwt.Handler, sessionSvc service.SessionService,
+	blacklist *middleware.SessionBlacklist) *OAuth2WechatHandler {
+	return &OAuth2WechatHandler{
+		jwtHandler: newJWTHandler(jwtHdl, blacklist, sessionSvc, mfaSvc),
+		svc:        svc,
+		userSvc:    userSvc,
+		stateKey:   stateKey,
+	}
+}
+
+type StateClaims struct {
+	jwt.RegisteredClaims
+	State string
+	// 不为 0 时表示给这个用户绑定微信，而不是登录
+	BindUID int64
+	// 绑定时微信属于另一个账号，是否把那个账号合并进来
+	Merge bool
+}
+
+func (h *OAuth2WechatHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/oauth2/wechat")
+	g.GET("/authurl", ginx.Wrap(h.AuthURL))
+	g.GET("/bind/authurl", ginx.WrapClaims(h.BindAuthURL))
+	g.Any("/callback", ginx.Wrap(h.Callback))
+}
+
+func (h *OAuth2WechatHandler) AuthURL(ctx *gin.Context) (ginx.Result, error) {
+	return h.authURL(ctx, StateClaims{})
+}
+
+// BindAuthURL 已登录的用户绑定微信，需要登录
+func (h *OAuth2WechatHandler) BindAuthURL(ctx *gin.Context, claims *middleware.JWTClaims) (ginx.Result, error) {
+	return h.authURL(ctx, StateClaims{
+		BindUID: claims.UserId,
+		Merge:   ctx.Query("merge") == "true",
+	})
+}
+
+func (h *OAuth2WechatHandler) authURL(ctx *gin.Context, sc StateClaims) (ginx.Result, error) {
+	state, err := h.newState()
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	url, err := h.svc.AuthURL(ctx, state)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	sc.State = state
+	if err = h.setStateCookie(ctx, sc); err != nil {
+		return ginx.Result{}, err
+	}
+	return ginx.Result{Data: url}, nil
+}
+
+func (h *OAuth2WechatHandler) Callback(ctx *gin.Context) (ginx.Result, error) {
+	sc, err := h.verifyState(ctx)
+	if err != nil {
+		slog.Warn("校验 state 失败", slog.Any("err", err))
+		return ginx.Result{}, errs.New(errs.WechatStateInvalid, "登录失败，请重新扫码")
+	}
+	info, err := h.svc.VerifyCode(ctx, ctx.Query("code"))
+	if err != nil {
+		slog.Warn("微信授权码校验失败", slog.Any("err", err))
+		return ginx.Result{}, errs.New(errs.WechatCodeInvalid, "授权码有误")
+	}
+	if sc.BindUID != 0 {
+		mergedID, err := h.userSvc.BindWechat(ctx, sc.BindUID, info, sc.Merge)
+		if err != nil {
+			return ginx.Result{}, err
+		}
+		if err = h.revokeMerged(ctx, mergedID); err != nil {
+			return ginx.Result{}, err
+		}
+		return ginx.Result{Msg: "绑定成功"}, nil
+	}
+	user, err := h.userSvc.FindOrCreateByWechat(ctx, info)
+	if err != nil {
+		return ginx.Result{}, err
+	}
+	return h.login(ctx, user.Id, loginMethodWechat)
+}
+
+func (h *OAuth2WechatHandler) newState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// setStateCookie 把签过名的 state 放到 cookie 里，回调时和微信带回来的 state 比对，防止 CSRF
+func (h *OAuth2WechatHandler) setStateCookie(ctx *gin.Context, claims StateClaims) error {
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(stateExpire))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	tokenStr, err := token.SignedString(h.stateKey)
+	if err != nil {
+		return err
+	}
+	ctx.SetCookie(stateCookieName, tokenStr, int(stateExpire.Seconds()),
+		"/oauth2/wechat/callback", "", false, true)
+	return nil
+}
+
+func (h *OAuth2WechatHandler) verifyState(ctx *gin.Context) (StateClaims, error) {
+	var claims StateClaims
+	tokenStr, err := ctx.Cookie(stateCookieName)
+	if err != nil {
+		return claims, err
+	}
+	token, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		return h.stateKey, nil
+	})
+	if err != nil {
+		return claims, err
+	}
+	if !token.Valid || claims.State != ctx.Query("state") {
+		return claims, errStateMismatch
+	}
+	return claims, nil
+}
@@ -0,0 +1,16 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitUserCache(cmd redis.Cmdable) cache.UserCache {
+	cfg := config.Current().Cache.User
+	c := cache.NewUserCache(cmd, cfg.Expiration, cfg.Jitter)
+	if cfg.Local.Enabled {
+		c = cache.NewLocalUserCache(c, cfg.Local.Size, cfg.Local.Expiration)
+	}
+	return c
+}
@@ -0,0 +1,38 @@
+package ioc
+
+import (
+	"time"
+
+	mysqldrv "github.com/go-sql-driver/mysql"
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func InitDB() *gorm.DB {
+	dsn, err := mysqldrv.ParseDSN(config.Current().DB.DSN)
+	if err != nil {
+		panic(err)
+	}
+	// 生日是 DATE 类型，需要解析成 time.Time，统一使用 UTC
+	dsn.ParseTime = true
+	dsn.Loc = time.UTC
+	// 初始化数据库连接
+	db, err := gorm.Open(mysql.Open(dsn.FormatDSN()))
+	if err != nil {
+		panic(err)
+	}
+
+	// 建表
+	err = dao.InitTable(db)
+	if err != nil {
+		// 如果不成功则panic
+		panic(err)
+	}
+	return db
+}
+
+func InitUserDAO(db *gorm.DB) dao.UserDAO {
+	return dao.NewUserDAO(db, config.Current().Profile.UniqueNickname)
+}
@@ -0,0 +1,45 @@
+package ioc
+
+import (
+	"log"
+
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+)
+
+func InitJWTHandler() ijwt.Handler {
+	cfg := config.Current().JWT
+	var keys []*ijwt.Key
+	for _, kc := range cfg.Keys {
+		k, err := ijwt.LoadKey(ijwt.KeyConfig{
+			Kid:            kc.Kid,
+			Alg:            kc.Alg,
+			PrivateKeyFile: kc.PrivateKeyFile,
+			PublicKeyFile:  kc.PublicKeyFile,
+		})
+		if err != nil {
+			panic(err)
+		}
+		keys = append(keys, k)
+	}
+	signingKid := cfg.SigningKid
+	if len(keys) == 0 {
+		log.Println("没有配置 JWT 密钥，使用临时生成的密钥，重启后需要重新登录")
+		k, err := ijwt.GenerateEd25519Key("dev")
+		if err != nil {
+			panic(err)
+		}
+		keys = append(keys, k)
+		signingKid = k.Kid
+	}
+	access, err := ijwt.NewKeySet(signingKid, keys...)
+	if err != nil {
+		panic(err)
+	}
+	refreshKey := ijwt.NewHMACKey("refresh", []byte(cfg.RefreshSecret))
+	refresh, err := ijwt.NewKeySet(refreshKey.Kid, refreshKey)
+	if err != nil {
+		panic(err)
+	}
+	return ijwt.NewHandler(access, refresh)
+}
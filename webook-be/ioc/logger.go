@@ -0,0 +1,13 @@
+package ioc
+
+import (
+	"log/slog"
+	"os"
+)
+
+// InitLogger 初始化结构化日志，同时设置为 slog 的默认 logger
+func InitLogger() *slog.Logger {
+	l := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(l)
+	return l
+}
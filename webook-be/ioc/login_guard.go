@@ -0,0 +1,38 @@
+package ioc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/captcha"
+	"github.com/newton-miku/webook/webook-be/internal/service/captcha/siteverify"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitLoginAttemptCache(cmd redis.Cmdable) cache.LoginAttemptCache {
+	cfg := config.Current().LoginProtect
+	return cache.NewLoginAttemptCache(cmd, cfg.Window, cfg.MaxEmailFailures, cfg.MaxIPFailures, cfg.LockDuration)
+}
+
+// InitLoginGuard 关闭登录保护时不统计失败次数
+func InitLoginGuard(repo repository.LoginAttemptRepository) service.LoginGuard {
+	cfg := config.Current().LoginProtect
+	if !cfg.Enabled {
+		return service.NewNopLoginGuard()
+	}
+	opts := service.LoginGuardOptions{
+		BaseDelay: cfg.BaseDelay,
+		MaxDelay:  cfg.MaxDelay,
+	}
+	var verifier captcha.Verifier
+	if cfg.Captcha.Enabled {
+		opts.CaptchaThreshold = cfg.Captcha.Threshold
+		verifier = siteverify.NewVerifier(&http.Client{Timeout: 5 * time.Second},
+			cfg.Captcha.VerifyURL, cfg.Captcha.Secret)
+	}
+	return service.NewLoginGuard(repo, verifier, opts)
+}
@@ -0,0 +1,39 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail/console"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail/file"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitMailService() mail.Service {
+	cfg := config.Current().Mail
+	if cfg.Driver == "file" {
+		svc, err := file.NewService(cfg.Dir, cfg.From)
+		if err != nil {
+			panic(err)
+		}
+		return svc
+	}
+	return console.NewService()
+}
+
+func InitEmailVerifyService(repo repository.UserRepository, mailSvc mail.Service, cmd redis.Cmdable) service.EmailVerifyService {
+	cfg := config.Current().EmailVerify
+	return service.NewEmailVerifyService(repo, mailSvc, initMailLimiter(cmd), service.EmailVerifyRequirement(cfg.Require),
+		[]byte(cfg.Secret), cfg.Expiration, cfg.URL)
+}
+
+// initMailLimiter 按收件人限制重置密码、验证邮箱的邮件，关闭时返回 nil
+func initMailLimiter(cmd redis.Cmdable) limiter.Limiter {
+	cfg := config.Current().RateLimit.Mail
+	if !cfg.Enabled {
+		return nil
+	}
+	return limiter.NewRedisSlidingWindowLimiter(cmd, cfg.Interval, cfg.Rate)
+}
@@ -0,0 +1,29 @@
+package ioc
+
+import (
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitMFALoginCache(cmd redis.Cmdable) cache.MFALoginCache {
+	cfg := config.Current().MFA
+	return cache.NewMFALoginCache(cmd, cfg.PendingExpiration, cfg.FailureWindow, cfg.MaxFailures, cfg.LockDuration)
+}
+
+func InitMFAService(repo repository.MFARepository, userRepo repository.UserRepository) service.MFAService {
+	cfg := config.Current().MFA
+	svc, err := service.NewMFAService(repo, userRepo, service.MFAOptions{
+		Issuer:     cfg.Issuer,
+		EncryptKey: []byte(cfg.EncryptKey),
+		Now:        time.Now,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return svc
+}
@@ -0,0 +1,52 @@
+package ioc
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/pkg/objstore"
+	"github.com/newton-miku/webook/webook-be/pkg/objstore/local"
+	"github.com/newton-miku/webook/webook-be/pkg/objstore/s3"
+)
+
+func InitObjStore() objstore.Store {
+	cfg := config.Current().ObjStore
+	if cfg.Driver == "s3" {
+		return s3.NewStore(&http.Client{Timeout: 10 * time.Second}, s3.Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PublicURL: cfg.S3.PublicURL,
+		})
+	}
+	return local.NewStore(cfg.Local.Dir, cfg.Local.BaseURL)
+}
+
+func InitAvatarService(repo repository.UserRepository, store objstore.Store) service.AvatarService {
+	cfg := config.Current().Avatar
+	return service.NewAvatarService(repo, store, service.AvatarOptions{
+		MaxBytes:     cfg.MaxBytes,
+		MaxDimension: cfg.MaxDimension,
+		Sizes:        cfg.Sizes,
+	})
+}
+
+// mountLocalObjStore 使用本地存储时，在 baseURL 的路径下提供存储目录中的文件
+func mountLocalObjStore(server *gin.Engine) {
+	cfg := config.Current().ObjStore
+	if cfg.Driver != "local" {
+		return
+	}
+	u, err := url.Parse(cfg.Local.BaseURL)
+	if err != nil {
+		panic(err)
+	}
+	server.Static(u.Path, cfg.Local.Dir)
+}
@@ -0,0 +1,31 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/mail"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitPasswordPolicy() *service.PasswordPolicy {
+	cfg := config.Current().Password
+	return service.NewPasswordPolicy(service.PasswordPolicy{
+		MinLength:     cfg.MinLength,
+		MaxBytes:      cfg.MaxBytes,
+		RequireLetter: cfg.RequireLetter,
+		RequireUpper:  cfg.RequireUpper,
+		RequireLower:  cfg.RequireLower,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+		DisallowEmail: cfg.DisallowEmail,
+		CheckCommon:   cfg.CheckCommon,
+	})
+}
+
+func InitPasswordResetService(userRepo repository.UserRepository, repo repository.PasswordResetRepository,
+	mailSvc mail.Service, policy *service.PasswordPolicy, cmd redis.Cmdable) service.PasswordResetService {
+	cfg := config.Current().Password.Reset
+	return service.NewPasswordResetService(userRepo, repo, mailSvc, initMailLimiter(cmd), policy,
+		[]byte(cfg.Secret), cfg.Expiration, cfg.URL)
+}
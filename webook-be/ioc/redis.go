@@ -0,0 +1,12 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitRedis() redis.Cmdable {
+	return redis.NewClient(&redis.Options{
+		Addr: config.Current().Redis.Addr,
+	})
+}
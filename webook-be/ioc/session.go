@@ -0,0 +1,19 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/redis/go-redis/v9"
+)
+
+// 登录记录的有效期和 refresh token 保持一致
+
+func InitSessionCache(cmd redis.Cmdable) *cache.SessionCache {
+	return cache.NewSessionCache(cmd, middleware.RefreshExpire)
+}
+
+func InitSessionService(repo *repository.SessionRepository) service.SessionService {
+	return service.NewSessionService(repo, middleware.RefreshExpire)
+}
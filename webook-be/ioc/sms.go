@@ -0,0 +1,34 @@
+package ioc
+
+import (
+	"context"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/async"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/console"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/failover"
+	smsratelimit "github.com/newton-miku/webook/webook-be/internal/service/sms/ratelimit"
+	"github.com/newton-miku/webook/webook-be/internal/service/sms/retryable"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+// InitSMSService 同时会启动异步发送短信的后台任务
+func InitSMSService(asyncRepo *repository.AsyncSmsRepository, cmd redis.Cmdable) sms.Service {
+	// 本地开发只打印短信内容，接入真实服务商后追加到 failover 列表中即可
+	svcs := []sms.Service{
+		console.NewService(),
+	}
+	var svc sms.Service = retryable.NewService(failover.NewService(svcs), 2, 100*time.Millisecond)
+	if limitCfg := config.Current().RateLimit.SMS; limitCfg.Enabled {
+		svc = smsratelimit.NewService(svc, limiter.NewRedisSlidingWindowLimiter(cmd, limitCfg.Interval, limitCfg.Rate))
+	}
+
+	// 限流或者发送失败时转为异步发送
+	asyncSvc := async.NewService(svc, asyncRepo, 3)
+	asyncSvc.StartAsyncCycle(context.Background())
+	return asyncSvc
+}
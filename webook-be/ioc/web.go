@@ -0,0 +1,132 @@
+package ioc
+
+import (
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx/middleware/ratelimit"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler,
+	wechatHdl *web.OAuth2WechatHandler, jwksHdl *web.JWKSHandler) *gin.Engine {
+	// 不用 gin.Default，访问日志由 accessLog 输出到 slog
+	server := gin.New()
+	// 登录保护和限流都按 ClientIP 统计，只信任配置中的代理，避免伪造 X-Forwarded-For 绕过
+	cfg := config.Current().Web
+	if err := server.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(err)
+	}
+	if len(cfg.RemoteIPHeaders) > 0 {
+		server.RemoteIPHeaders = cfg.RemoteIPHeaders
+	}
+	server.Use(mdls...)
+	userHdl.RegisterRoutesV1(server.Group("/users"))
+	wechatHdl.RegisterRoutes(server)
+	jwksHdl.RegisterRoutes(server)
+	mountLocalObjStore(server)
+	server.GET("/ping", func(ctx *gin.Context) {
+		ctx.String(200, "pong")
+	})
+	return server
+}
+
+func InitMiddlewares(redisClient redis.Cmdable, l *slog.Logger, jwtHdl ijwt.Handler,
+	blacklist *middleware.SessionBlacklist, sessionSvc service.SessionService) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		// 处理跨域插件
+		cors.New(cors.Config{
+			AllowMethods:  []string{"GET", "POST", "PATCH"},
+			AllowHeaders:  []string{"Content-Type", "Authorization"},
+			ExposeHeaders: []string{"X-JWT-Token", "X-Refresh-Token"},
+			// 是否允许携带cookie
+			AllowCredentials: true,
+			// 每次都读取最新的配置，支持热更新
+			AllowOriginFunc: func(origin string) bool {
+				for _, allowed := range config.Current().CORS.AllowOrigins {
+					if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+						if strings.HasPrefix(origin, prefix) {
+							return true
+						}
+					} else if origin == allowed {
+						return true
+					}
+				}
+				return false
+			},
+			MaxAge: 12 * time.Hour,
+		}),
+		accessLog(l),
+		// 放在 accessLog 后面，panic 的请求也会记录访问日志
+		gin.Recovery(),
+		initIPRateLimit(redisClient),
+		initMailIPRateLimit(redisClient),
+		middleware.NewJWTLoginMiddleware(jwtHdl, blacklist).
+			RecordSession(sessionSvc).
+			AddIgnorePaths(config.Current().Web.IgnorePaths).
+			Build(),
+	}
+}
+
+// accessLog 用结构化日志记录每个请求
+func accessLog(l *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		l.Info("access",
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", ctx.Writer.Status()),
+			slog.String("ip", ctx.ClientIP()),
+			slog.Duration("latency", time.Since(start)))
+	}
+}
+
+// initMailIPRateLimit 按 IP 限制发送重置密码、验证邮箱的邮件
+// 按邮箱的限制在 service 中，换着邮箱发送时由这里拦住
+func initMailIPRateLimit(redisClient redis.Cmdable) gin.HandlerFunc {
+	cfg := config.Current().RateLimit.MailIP
+	if !cfg.Enabled {
+		return func(ctx *gin.Context) {}
+	}
+	paths := map[string]bool{
+		"/users/password/reset/send": true,
+		"/users/verify_email/send":   true,
+	}
+	limit := ratelimit.NewBuilder(limiter.NewRedisSlidingWindowLimiter(redisClient, cfg.Interval, cfg.Rate)).
+		Prefix("mail-ip-limiter").Build()
+	return func(ctx *gin.Context) {
+		if paths[ctx.FullPath()] {
+			limit(ctx)
+		}
+	}
+}
+
+// initIPRateLimit 按 IP 限流，配置修改后重新构建限流器
+func initIPRateLimit(redisClient redis.Cmdable) gin.HandlerFunc {
+	var hdl atomic.Pointer[gin.HandlerFunc]
+	build := func(cfg config.LimitConfig) {
+		var h gin.HandlerFunc = func(ctx *gin.Context) {}
+		if cfg.Enabled {
+			h = ratelimit.NewBuilder(limiter.NewRedisSlidingWindowLimiter(redisClient, cfg.Interval, cfg.Rate)).Build()
+		}
+		hdl.Store(&h)
+	}
+	build(config.Current().RateLimit.IP)
+	config.OnChange(func(cfg *config.Config) {
+		build(cfg.RateLimit.IP)
+	})
+	return func(ctx *gin.Context) {
+		(*hdl.Load())(ctx)
+	}
+}
@@ -0,0 +1,24 @@
+package ioc
+
+import (
+	"github.com/newton-miku/webook/webook-be/internal/config"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/service/oauth2/wechat"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	ijwt "github.com/newton-miku/webook/webook-be/internal/web/jwt"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+)
+
+func InitWechatService() wechat.Service {
+	cfg := config.Current().Wechat
+	if cfg.Fake {
+		return wechat.NewFakeService(cfg.RedirectURL)
+	}
+	return wechat.NewService(cfg.AppID, cfg.AppSecret, cfg.RedirectURL)
+}
+
+func InitOAuth2WechatHandler(svc wechat.Service, userSvc service.UserService, mfaSvc service.MFAService,
+	jwtHdl ijwt.Handler, sessionSvc service.SessionService, blacklist *middleware.SessionBlacklist) *web.OAuth2WechatHandler {
+	stateKey := []byte(config.Current().Wechat.StateKey)
+	return web.NewOAuth2WechatHandler(svc, userSvc, stateKey, mfaSvc, jwtHdl, sessionSvc, blacklist)
+}
@@ -0,0 +1,60 @@
+// Package errs 定义返回给前端的业务错误码
+package errs
+
+// Code 业务错误码，0 表示成功
+// 4xxxx 是用户请求有误，5xxxx 是系统错误
+// 中间两位区分模块：00 通用，01 用户，02 验证码，03 微信登录
+type Code int
+
+const (
+	OK Code = 0
+
+	// InvalidParam 参数格式有误，具体原因放在提示信息中
+	InvalidParam Code = 40000
+
+	UserDuplicateEmail    Code = 40101
+	UserInvalidCredential Code = 40102
+	UserNotFound          Code = 40103
+	// UserIdentityBoundToOther 前端收到后询问用户是否合并账号
+	UserIdentityBoundToOther Code = 40104
+	UserIdentityAlreadyBound Code = 40105
+	UserLastIdentity         Code = 40106
+	UserSessionNotFound      Code = 40107
+	UserDuplicatePhone       Code = 40108
+	UserDuplicateWechat      Code = 40109
+	// UserWeakPassword 密码不符合密码策略，具体原因放在提示信息中
+	UserWeakPassword Code = 40110
+	// UserWrongPassword 修改密码时原密码不对
+	UserWrongPassword     Code = 40111
+	UserResetTokenInvalid Code = 40112
+	// UserEmailNotVerified 邮箱验证之前不允许登录或者发帖，取决于配置
+	UserEmailNotVerified        Code = 40113
+	UserEmailVerifyTokenInvalid Code = 40114
+	// UserLoginLocked 登录失败次数过多，提示信息中有需要等待的时间
+	UserLoginLocked Code = 40115
+	// UserCaptchaRequired 需要人机验证，或者人机验证没有通过
+	UserCaptchaRequired Code = 40116
+	// UserMFARequired 开启了两步验证，Data 中有完成登录使用的 mfaToken
+	UserMFARequired       Code = 40117
+	UserMFACodeInvalid    Code = 40118
+	UserMFATokenInvalid   Code = 40119
+	UserMFAAlreadyEnabled Code = 40120
+	UserMFANotEnabled     Code = 40121
+	// UserDuplicateNickname 开启了昵称唯一时，昵称已经被其它用户使用
+	UserDuplicateNickname Code = 40122
+	// UserProfileConflict 档案在读取之后被其它请求修改过，前端需要重新读取档案，HTTP 状态码为 409
+	UserProfileConflict Code = 40123
+	// UserMergeConflict 两个账号有同一种登录方式，或者被合并的账号开启了两步验证，不允许合并
+	UserMergeConflict Code = 40124
+	// UserMailSendTooMany 同一个邮箱发送重置密码、验证邮件太频繁
+	UserMailSendTooMany Code = 40125
+
+	CodeSendTooMany   Code = 40201
+	CodeVerifyTooMany Code = 40202
+	CodeInvalid       Code = 40203
+
+	WechatStateInvalid Code = 40301
+	WechatCodeInvalid  Code = 40302
+
+	InternalServerError Code = 50000
+)
@@ -0,0 +1,42 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error 带业务错误码的错误，Msg 会直接展示给用户
+type Error struct {
+	Code Code
+	Msg  string
+}
+
+func New(code Code, msg string) *Error {
+	return &Error{
+		Code: code,
+		Msg:  msg,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Msg)
+}
+
+// Is 错误码相同就是同一种错误，提示信息可以不同
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// internal 没有错误码的错误都当作系统错误，不把原因暴露给用户
+var internal = New(InternalServerError, "系统错误，请稍后再试")
+
+// From 取出 err 中的业务错误，没有时返回系统错误
+// 第二个返回值表示 err 是否为业务错误
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return internal, false
+}
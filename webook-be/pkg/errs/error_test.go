@@ -0,0 +1,24 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := New(UserNotFound, "用户不存在")
+
+	e, ok := From(fmt.Errorf("查询用户: %w", notFound))
+	assert.True(t, ok)
+	assert.Equal(t, notFound, e)
+	// 错误码相同就认为是同一种错误
+	assert.ErrorIs(t, New(UserNotFound, "账号已注销"), notFound)
+	assert.NotErrorIs(t, New(UserDuplicateEmail, "用户不存在"), notFound)
+
+	e, ok = From(errors.New("db 错误"))
+	assert.False(t, ok)
+	assert.Equal(t, InternalServerError, e.Code)
+}
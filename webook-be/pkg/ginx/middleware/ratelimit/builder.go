@@ -1,33 +1,23 @@
 package ratelimit
 
 import (
-	_ "embed"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
+	"github.com/newton-miku/webook/webook-be/pkg/limiter"
 )
 
 type Builder struct {
-	prefix   string
-	cmd      redis.Cmdable
-	interval time.Duration
-	// 阈值
-	rate int
+	prefix  string
+	limiter limiter.Limiter
 }
 
-//go:embed slide_window.lua
-var luaScript string
-
-func NewBuilder(cmd redis.Cmdable, interval time.Duration, rate int) *Builder {
+func NewBuilder(l limiter.Limiter) *Builder {
 	return &Builder{
-		cmd:      cmd,
-		prefix:   "ip-limiter",
-		interval: interval,
-		rate:     rate,
+		limiter: l,
+		prefix:  "ip-limiter",
 	}
 }
 
@@ -38,15 +28,16 @@ func (b *Builder) Prefix(prefix string) *Builder {
 
 func (b *Builder) Build() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		limited, err := b.limit(ctx)
+		key := b.key(ctx)
+		limited, err := b.limiter.Limit(ctx, key)
 		if err != nil {
-			log.Println(err)
 			// 访问redis出错
+			slog.Error("限流器出错", slog.String("key", key), slog.Any("err", err))
 			ctx.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 		if limited {
-			log.Println(err)
+			slog.Warn("请求触发限流", slog.String("key", key))
 			ctx.AbortWithStatus(http.StatusTooManyRequests)
 			return
 		}
@@ -54,8 +45,6 @@ func (b *Builder) Build() gin.HandlerFunc {
 	}
 }
 
-func (b *Builder) limit(ctx *gin.Context) (bool, error) {
-	key := fmt.Sprintf("%s:%s", b.prefix, ctx.ClientIP())
-	return b.cmd.Eval(ctx, luaScript, []string{key},
-		b.interval.Milliseconds(), b.rate, time.Now().UnixMilli()).Bool()
+func (b *Builder) key(ctx *gin.Context) string {
+	return fmt.Sprintf("%s:%s", b.prefix, ctx.ClientIP())
 }
@@ -0,0 +1,45 @@
+package ginx
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+)
+
+// Result 所有接口统一的响应格式，结果看 Code
+// HTTP 状态码一般都是 200，只有 httpStatus 中的错误码例外
+type Result struct {
+	Code errs.Code `json:"code"`
+	Msg  string    `json:"msg"`
+	Data any       `json:"data"`
+}
+
+// httpStatus 需要用 HTTP 状态码表达的业务错误，方便网关、缓存等只看状态码的组件处理
+var httpStatus = map[errs.Code]int{
+	errs.UserProfileConflict: http.StatusConflict,
+}
+
+// Render 输出处理结果
+// err 为 nil 时输出 res；err 为业务错误时输出错误码和提示；其它错误记录日志后统一返回系统错误
+func Render(ctx *gin.Context, res Result, err error) {
+	if err != nil {
+		e, ok := errs.From(err)
+		if !ok {
+			slog.Error("处理请求失败",
+				slog.String("method", ctx.Request.Method),
+				slog.String("path", ctx.FullPath()),
+				slog.Any("err", err))
+		}
+		res = Result{
+			Code: e.Code,
+			Msg:  e.Msg,
+		}
+	}
+	status, ok := httpStatus[res.Code]
+	if !ok {
+		status = http.StatusOK
+	}
+	ctx.JSON(status, res)
+}
@@ -0,0 +1,65 @@
+package validation
+
+import "sync"
+
+const (
+	LocaleZH = "zh"
+	LocaleEN = "en"
+	// DefaultLocale 不认识的语言都使用中文
+	DefaultLocale = LocaleZH
+)
+
+// 提示信息模板，{0} 为字段名，{1} 为规则的参数
+var (
+	mu       sync.RWMutex
+	messages = map[string]map[string]string{
+		LocaleZH: {
+			"required": "{0}不能为空",
+			"email":    "{0}格式有误",
+			"phone":    "{0}格式有误",
+			"date":     "{0}格式有误，应为 YYYY-MM-DD",
+			"birthday": "{0}必须在1900年1月1日到今天之间",
+			"eqfield":  "{0}和{1}不一致",
+			"oneof":    "{0}必须是 {1} 中的一个",
+			"min":      "{0}长度不能少于{1}",
+			"max":      "{0}长度不能超过{1}",
+			"":         "{0}不合法",
+		},
+		LocaleEN: {
+			"required": "{0} is required",
+			"email":    "{0} must be a valid email address",
+			"phone":    "{0} must be a valid mobile phone number",
+			"date":     "{0} must be a date in YYYY-MM-DD format",
+			"birthday": "{0} must be between 1900-01-01 and today",
+			"eqfield":  "{0} must match {1}",
+			"oneof":    "{0} must be one of {1}",
+			"min":      "{0} must be at least {1} characters",
+			"max":      "{0} must be at most {1} characters",
+			"":         "{0} is invalid",
+		},
+	}
+)
+
+// RegisterMessage 注册或者覆盖 locale 下 tag 规则的提示信息
+func RegisterMessage(locale, tag, msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if messages[locale] == nil {
+		messages[locale] = map[string]string{}
+	}
+	messages[locale][tag] = msg
+}
+
+// message 找不到 tag 对应的提示时使用通用的提示
+func message(locale, tag string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	msgs, ok := messages[locale]
+	if !ok {
+		msgs = messages[DefaultLocale]
+	}
+	if msg, ok := msgs[tag]; ok {
+		return msg
+	}
+	return msgs[""]
+}
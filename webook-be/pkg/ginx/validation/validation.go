@@ -0,0 +1,90 @@
+// Package validation 在 gin 自带的 validator 上注册自定义规则，并把校验错误翻译为提示信息
+//
+// 请求结构体上用 binding 声明规则，label 声明中文字段名，英文提示使用 json 中的字段名：
+//
+//	Email string `json:"email" binding:"required,email" label:"邮箱"`
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		panic("gin 的校验器不是 validator.Validate")
+	}
+	for tag, fn := range validators {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// FieldError 一个字段的校验错误
+type FieldError struct {
+	// json 中的字段名
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// Translate 把 err 中所有的校验错误翻译为 locale 对应的提示
+// req 为绑定的请求结构体，用于查找字段名；err 不是校验错误时返回 false
+func Translate(req any, err error, locale string) ([]FieldError, bool) {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil, false
+	}
+	typ := reflect.TypeOf(req)
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	res := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		msg := message(locale, fe.Tag())
+		msg = strings.ReplaceAll(msg, "{0}", fieldName(typ, fe.StructField(), locale))
+		param := fe.Param()
+		if fe.Tag() == "eqfield" {
+			// 参数是另一个字段
+			param = fieldName(typ, param, locale)
+		}
+		msg = strings.ReplaceAll(msg, "{1}", param)
+		res = append(res, FieldError{
+			Field: fieldName(typ, fe.StructField(), LocaleEN),
+			Msg:   msg,
+		})
+	}
+	return res, true
+}
+
+// Locale 根据 Accept-Language 选择提示信息的语言
+func Locale(acceptLanguage string) string {
+	lang := strings.ToLower(strings.TrimSpace(acceptLanguage))
+	if strings.HasPrefix(lang, LocaleEN) {
+		return LocaleEN
+	}
+	return DefaultLocale
+}
+
+// fieldName 中文使用 label，英文使用 json 中的字段名
+func fieldName(typ reflect.Type, name, locale string) string {
+	if typ.Kind() != reflect.Struct {
+		return name
+	}
+	f, ok := typ.FieldByName(name)
+	if !ok {
+		return name
+	}
+	if label := f.Tag.Get("label"); label != "" && locale == LocaleZH {
+		return label
+	}
+	if jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+		return jsonName
+	}
+	return name
+}
@@ -0,0 +1,50 @@
+package validation
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	// 邮箱用户名部分可以包含字母、数字、点、下划线、百分号、加号和减号
+	emailReg = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	// 中国大陆 11 位手机号
+	phoneReg = regexp.MustCompile(`^1[3-9]\d{9}$`)
+	// 允许的最早生日
+	minBirthday = time.Date(1900, 1, 1, 0, 0, 0, 0, time.Local)
+)
+
+// validators 自定义的校验规则，email 覆盖了 validator 自带的规则
+var validators = map[string]validator.Func{
+	"email":    validateEmail,
+	"phone":    validatePhone,
+	"date":     validateDate,
+	"birthday": validateBirthday,
+}
+
+func validateEmail(fl validator.FieldLevel) bool {
+	return emailReg.MatchString(fl.Field().String())
+}
+
+func validatePhone(fl validator.FieldLevel) bool {
+	return phoneReg.MatchString(fl.Field().String())
+}
+
+// validateDate 格式为 2006-01-02 的合法日期
+func validateDate(fl validator.FieldLevel) bool {
+	_, err := time.ParseInLocation(dateLayout, fl.Field().String(), time.Local)
+	return err == nil
+}
+
+// validateBirthday 在 1900-01-01 到今天之间的日期
+func validateBirthday(fl validator.FieldLevel) bool {
+	t, err := time.ParseInLocation(dateLayout, fl.Field().String(), time.Local)
+	if err != nil {
+		return false
+	}
+	return !t.Before(minBirthday) && !t.After(time.Now())
+}
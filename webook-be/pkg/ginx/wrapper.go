@@ -0,0 +1,100 @@
+package ginx
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/newton-miku/webook/webook-be/pkg/ginx/validation"
+)
+
+// ClaimsKey 登录校验通过后，claims 保存在 gin.Context 中的 key
+const ClaimsKey = "claims"
+
+var errNoClaims = errors.New("未找到登录信息")
+
+// Wrap 把返回 Result 的函数转换为 gin.HandlerFunc，统一输出结果和记录日志
+func Wrap(fn func(ctx *gin.Context) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res, err := fn(ctx)
+		Render(ctx, res, err)
+	}
+}
+
+// WrapBody 先把请求绑定到 Req 上并校验，失败时不会调用 fn
+func WrapBody[Req any](fn func(ctx *gin.Context, req Req) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req Req
+		if !bind(ctx, &req) {
+			return
+		}
+		res, err := fn(ctx, req)
+		Render(ctx, res, err)
+	}
+}
+
+// WrapClaims 取出登录校验时保存的 claims，需要登录的接口使用
+func WrapClaims[C any](fn func(ctx *gin.Context, claims C) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, err := getClaims[C](ctx)
+		if err != nil {
+			Render(ctx, Result{}, err)
+			return
+		}
+		res, err := fn(ctx, claims)
+		Render(ctx, res, err)
+	}
+}
+
+// WrapBodyAndClaims 同时绑定请求和取出 claims
+func WrapBodyAndClaims[Req any, C any](fn func(ctx *gin.Context, req Req, claims C) (Result, error)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req Req
+		if !bind(ctx, &req) {
+			return
+		}
+		claims, err := getClaims[C](ctx)
+		if err != nil {
+			Render(ctx, Result{}, err)
+			return
+		}
+		res, err := fn(ctx, req, claims)
+		Render(ctx, res, err)
+	}
+}
+
+// getClaims 没有 claims 说明路由没有经过登录校验，属于系统错误
+func getClaims[C any](ctx *gin.Context) (C, error) {
+	val, _ := ctx.Get(ClaimsKey)
+	claims, ok := val.(C)
+	if !ok {
+		return claims, errNoClaims
+	}
+	return claims, nil
+}
+
+// bind 绑定并校验请求
+// 请求体无法解析时返回 400，校验不通过时一次性返回所有字段的错误，data 中为每个字段的提示
+func bind(ctx *gin.Context, req any) bool {
+	err := ctx.ShouldBind(req)
+	if err == nil {
+		return true
+	}
+	fieldErrs, ok := validation.Translate(req, err, validation.Locale(ctx.GetHeader("Accept-Language")))
+	if !ok {
+		_ = ctx.AbortWithError(http.StatusBadRequest, err).SetType(gin.ErrorTypeBind)
+		return false
+	}
+	msgs := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		msgs = append(msgs, fe.Msg)
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Code: errs.InvalidParam,
+		Msg:  strings.Join(msgs, "；"),
+		Data: fieldErrs,
+	})
+	return false
+}
@@ -0,0 +1,113 @@
+package ginx
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/pkg/errs"
+	"github.com/stretchr/testify/assert"
+)
+
+type testReq struct {
+	Name string `json:"name"`
+}
+
+type testClaims struct {
+	Uid int64
+}
+
+func TestWrapBodyAndClaims(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fn       func(ctx *gin.Context, req testReq, claims *testClaims) (Result, error)
+		claims   any
+		reqBody  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "成功",
+			fn: func(ctx *gin.Context, req testReq, claims *testClaims) (Result, error) {
+				return Result{Msg: req.Name, Data: claims.Uid}, nil
+			},
+			claims:   &testClaims{Uid: 123},
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":0,"msg":"miku","data":123}`,
+		},
+		{
+			name:     "bind 失败",
+			claims:   &testClaims{Uid: 123},
+			reqBody:  `{"name":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "没有 claims",
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+		{
+			name:     "claims 类型不对",
+			claims:   testClaims{Uid: 123},
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+		{
+			name: "业务错误",
+			fn: func(ctx *gin.Context, req testReq, claims *testClaims) (Result, error) {
+				return Result{Data: "不会返回"}, errs.New(errs.InvalidParam, "名字有误")
+			},
+			claims:   &testClaims{Uid: 123},
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":40000,"msg":"名字有误","data":null}`,
+		},
+		{
+			name: "有对应 HTTP 状态码的业务错误",
+			fn: func(ctx *gin.Context, req testReq, claims *testClaims) (Result, error) {
+				return Result{}, errs.New(errs.UserProfileConflict, "档案已经被修改")
+			},
+			claims:   &testClaims{Uid: 123},
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusConflict,
+			wantBody: `{"code":40123,"msg":"档案已经被修改","data":null}`,
+		},
+		{
+			name: "系统错误",
+			fn: func(ctx *gin.Context, req testReq, claims *testClaims) (Result, error) {
+				return Result{}, errors.New("db 错误")
+			},
+			claims:   &testClaims{Uid: 123},
+			reqBody:  `{"name":"miku"}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"code":50000,"msg":"系统错误，请稍后再试","data":null}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			server := gin.New()
+			server.POST("/test", func(ctx *gin.Context) {
+				if tc.claims != nil {
+					ctx.Set(ClaimsKey, tc.claims)
+				}
+			}, WrapBodyAndClaims(tc.fn))
+
+			req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewBufferString(tc.reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+
+			assert.Equal(t, tc.wantCode, resp.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, resp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package imagex 头像等图片的简单处理，只依赖标准库
+package imagex
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// CropSquare 从中间裁剪出最大的正方形
+func CropSquare(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// Flatten 把 img 中 src 区域画到 bg 上，得到从 (0, 0) 开始的不透明图片，JPEG 不支持透明
+// 生成多个尺寸时只需要调用一次，再用 Resize 分别缩放
+func Flatten(img image.Image, src image.Rectangle, bg color.Color) *image.RGBA {
+	flat := image.NewRGBA(image.Rect(0, 0, src.Dx(), src.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, src.Min, draw.Over)
+	return flat
+}
+
+// Resize 把不透明的 src 缩放为 width * height，透明度会被忽略，需要先用 Flatten 处理
+// 缩小时取源区域内像素的平均值，放大时取最近的像素
+func Resize(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	for y := range height {
+		y0 := y * sh / height
+		y1 := max((y+1)*sh/height, y0+1)
+		for x := range width {
+			x0 := x * sw / width
+			x1 := max((x+1)*sw/width, x0+1)
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := src.PixOffset(sb.Min.X+x0, sb.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(src.Pix[off])
+					g += uint32(src.Pix[off+1])
+					b += uint32(src.Pix[off+2])
+					off += 4
+					n++
+				}
+			}
+			off := dst.PixOffset(x, y)
+			dst.Pix[off] = uint8(r / n)
+			dst.Pix[off+1] = uint8(g / n)
+			dst.Pix[off+2] = uint8(b / n)
+			dst.Pix[off+3] = 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,48 @@
+package imagex
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCropSquare(t *testing.T) {
+	assert.Equal(t, image.Rect(50, 0, 150, 100), CropSquare(image.NewRGBA(image.Rect(0, 0, 200, 100))))
+	assert.Equal(t, image.Rect(10, 35, 50, 75), CropSquare(image.NewRGBA(image.Rect(10, 10, 50, 100))))
+}
+
+func TestFlatten(t *testing.T) {
+	// 右半边半透明的红色
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := 2; x < 4; x++ {
+			img.Set(x, y, color.NRGBA{R: 0xff, A: 0x80})
+		}
+	}
+	flat := Flatten(img, image.Rect(1, 1, 3, 3), color.White)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), flat.Bounds())
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, flat.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0x7f, B: 0x7f, A: 0xff}, flat.RGBAAt(1, 1))
+}
+
+func TestResize(t *testing.T) {
+	// 左半边红色，右半边透明
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 2 {
+			img.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+	flat := Flatten(img, img.Bounds(), color.White)
+	dst := Resize(flat, 2, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, dst.RGBAAt(0, 1))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, dst.RGBAAt(1, 0))
+
+	// 放大时取最近的像素
+	dst = Resize(flat, 8, 8)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 0xff}, dst.RGBAAt(3, 7))
+	assert.Equal(t, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, dst.RGBAAt(4, 0))
+}
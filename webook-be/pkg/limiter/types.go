@@ -0,0 +1,10 @@
+package limiter
+
+import "context"
+
+//go:generate go run go.uber.org/mock/mockgen -source=types.go -package=limitermocks -destination=mocks/types.mock.go Limiter
+type Limiter interface {
+	// Limit 判断 key 是否需要限流
+	// 返回 true 表示需要限流
+	Limit(ctx context.Context, key string) (bool, error)
+}
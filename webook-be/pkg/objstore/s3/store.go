@@ -0,0 +1,118 @@
+// Package s3 兼容 S3 的对象存储，可以对接 AWS S3、MinIO 等
+// 使用 path-style 的地址，即 endpoint/bucket/key
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/newton-miku/webook/webook-be/pkg/objstore"
+)
+
+type Config struct {
+	// 如 http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// 对外访问的地址，如 CDN 的地址，为空时使用 endpoint/bucket
+	PublicURL string
+}
+
+type Store struct {
+	client    *http.Client
+	endpoint  string
+	bucket    string
+	publicURL string
+	signer    signer
+	now       func() time.Time
+}
+
+func NewStore(client *http.Client, cfg Config) *Store {
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/")
+	publicURL := strings.TrimSuffix(cfg.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + cfg.Bucket
+	}
+	return &Store{
+		client:    client,
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		publicURL: publicURL,
+		signer: signer{
+			accessKey: cfg.AccessKey,
+			secretKey: cfg.SecretKey,
+			region:    cfg.Region,
+		},
+		now: time.Now,
+	}
+}
+
+// Put 请求体需要参与签名，所以先读到内存中，只适合头像这样的小文件
+func (s *Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := objstore.CheckKey(key); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.signer.sign(req, hexSHA256(body), s.now())
+	return s.do(req)
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	if err := objstore.CheckKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	s.signer.sign(req, emptyPayloadSHA, s.now())
+	// 对象不存在时 S3 也返回 204
+	return s.do(req)
+}
+
+func (s *Store) URL(key string) string {
+	return s.publicURL + "/" + escapeKey(key)
+}
+
+func (s *Store) objectURL(key string) string {
+	return s.endpoint + "/" + url.PathEscape(s.bucket) + "/" + escapeKey(key)
+}
+
+func (s *Store) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		// 错误信息是 XML，只截取一部分用于排查
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("s3 %s %s 失败，状态码 %d：%s", req.Method, req.URL.Path, resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// escapeKey 逐段编码，保留 key 中的 /
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 类似 MinIO 的最小实现，只支持 PUT 和 DELETE
+// 会校验 x-amz-content-sha256，但不校验签名
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	body        string
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = fakeObject{body: string(body), contentType: r.Header.Get("Content-Type")}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestStore(t *testing.T) {
+	fake := &fakeS3{objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	store := NewStore(server.Client(), Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "webook",
+		AccessKey: "minio",
+		SecretKey: "minio-secret",
+	})
+	ctx := context.Background()
+
+	err := store.Put(ctx, "avatars/123/a b.jpg", strings.NewReader("jpeg"), 4, "image/jpeg")
+	require.NoError(t, err)
+	assert.Equal(t, fakeObject{body: "jpeg", contentType: "image/jpeg"}, fake.objects["/webook/avatars/123/a b.jpg"])
+	assert.Equal(t, server.URL+"/webook/avatars/123/a%20b.jpg", store.URL("avatars/123/a b.jpg"))
+
+	require.NoError(t, store.Delete(ctx, "avatars/123/a b.jpg"))
+	assert.Empty(t, fake.objects)
+
+	assert.Error(t, store.Put(ctx, "../passwd", strings.NewReader("x"), 1, "text/plain"))
+
+	bad := NewStore(server.Client(), Config{Endpoint: server.URL, Region: "us-east-1", Bucket: "webook", AccessKey: "bad"})
+	assert.ErrorContains(t, bad.Put(ctx, "a.jpg", strings.NewReader("x"), 1, "image/jpeg"), "403")
+}
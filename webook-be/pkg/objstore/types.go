@@ -0,0 +1,32 @@
+// Package objstore 对象存储，保存头像等用户上传的文件
+package objstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("对象的 key 不合法")
+
+//go:generate go run go.uber.org/mock/mockgen -source=types.go -package=objstoremocks -destination=mocks/objstore.mock.go Store
+type Store interface {
+	// Put 上传对象，key 已经存在时覆盖
+	// key 形如 avatars/123/abc/256.jpg，不能以 / 开头，不能包含 ..
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// URL 返回对象的公开访问地址
+	URL(key string) string
+}
+
+// CheckKey 校验 key，避免写到存储目录之外
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key ||
+		key == ".." || strings.HasPrefix(key, "../") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+//go:build wireinject
+
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/wire"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/ioc"
+)
+
+func InitWebServer() *gin.Engine {
+	wire.Build(
+		// 第三方依赖
+		ioc.InitDB, ioc.InitRedis, ioc.InitLogger,
+
+		dao.ProviderSet,
+		ioc.InitUserDAO,
+		cache.ProviderSet,
+		ioc.InitSessionCache,
+		ioc.InitUserCache,
+		ioc.InitLoginAttemptCache,
+		ioc.InitMFALoginCache,
+		repository.ProviderSet,
+
+		ioc.InitSMSService,
+		ioc.InitWechatService,
+		ioc.InitSessionService,
+		ioc.InitPasswordPolicy,
+		ioc.InitMailService,
+		ioc.InitPasswordResetService,
+		ioc.InitEmailVerifyService,
+		ioc.InitLoginGuard,
+		ioc.InitMFAService,
+		ioc.InitObjStore,
+		ioc.InitAvatarService,
+		service.ProviderSet,
+
+		ioc.InitJWTHandler,
+		middleware.ProviderSet,
+		web.ProviderSet,
+		ioc.InitOAuth2WechatHandler,
+
+		ioc.InitMiddlewares,
+		ioc.InitWebServer,
+	)
+	return new(gin.Engine)
+}
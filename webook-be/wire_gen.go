@@ -0,0 +1,63 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/newton-miku/webook/webook-be/internal/repository"
+	"github.com/newton-miku/webook/webook-be/internal/repository/cache"
+	"github.com/newton-miku/webook/webook-be/internal/repository/dao"
+	"github.com/newton-miku/webook/webook-be/internal/service"
+	"github.com/newton-miku/webook/webook-be/internal/web"
+	"github.com/newton-miku/webook/webook-be/internal/web/middleware"
+	"github.com/newton-miku/webook/webook-be/ioc"
+)
+
+// Injectors from wire.go:
+
+func InitWebServer() *gin.Engine {
+	cmdable := ioc.InitRedis()
+	logger := ioc.InitLogger()
+	handler := ioc.InitJWTHandler()
+	sessionBlacklist := middleware.NewSessionBlacklist(cmdable)
+	sessionCache := ioc.InitSessionCache(cmdable)
+	sessionRepository := repository.NewSessionRepository(sessionCache)
+	sessionService := ioc.InitSessionService(sessionRepository)
+	v := ioc.InitMiddlewares(cmdable, logger, handler, sessionBlacklist, sessionService)
+	db := ioc.InitDB()
+	userDAO := ioc.InitUserDAO(db)
+	userCache := ioc.InitUserCache(cmdable)
+	userRepository := repository.NewUserRepository(userDAO, userCache)
+	passwordPolicy := ioc.InitPasswordPolicy()
+	userService := service.NewUserService(userRepository, passwordPolicy)
+	codeCache := cache.NewCodeCache(cmdable)
+	codeRepository := repository.NewCodeRepository(codeCache)
+	asyncSmsDAO := dao.NewAsyncSmsDAO(db)
+	asyncSmsRepository := repository.NewAsyncSmsRepository(asyncSmsDAO)
+	smsService := ioc.InitSMSService(asyncSmsRepository, cmdable)
+	codeService := service.NewCodeService(codeRepository, smsService)
+	passwordResetDAO := dao.NewPasswordResetDAO(db)
+	passwordResetRepository := repository.NewPasswordResetRepository(passwordResetDAO)
+	mailService := ioc.InitMailService()
+	passwordResetService := ioc.InitPasswordResetService(userRepository, passwordResetRepository, mailService, passwordPolicy, cmdable)
+	emailVerifyService := ioc.InitEmailVerifyService(userRepository, mailService, cmdable)
+	loginAttemptCache := ioc.InitLoginAttemptCache(cmdable)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(loginAttemptCache)
+	loginGuard := ioc.InitLoginGuard(loginAttemptRepository)
+	mfadao := dao.NewMFADAO(db)
+	mfaLoginCache := ioc.InitMFALoginCache(cmdable)
+	mfaRepository := repository.NewMFARepository(mfadao, mfaLoginCache)
+	mfaService := ioc.InitMFAService(mfaRepository, userRepository)
+	store := ioc.InitObjStore()
+	avatarService := ioc.InitAvatarService(userRepository, store)
+	userHandler := web.NewUserHandler(userService, codeService, passwordResetService, emailVerifyService, loginGuard, mfaService, avatarService, handler, sessionService, sessionBlacklist)
+	wechatService := ioc.InitWechatService()
+	oAuth2WechatHandler := ioc.InitOAuth2WechatHandler(wechatService, userService, mfaService, handler, sessionService, sessionBlacklist)
+	jwksHandler := web.NewJWKSHandler(handler)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, jwksHandler)
+	return engine
+}
@@ -31,6 +31,10 @@ instance.interceptors.response.use(function (resp) {
     if (err.response.status == 401) {
         window.location.href="/users/login"
     }
+    // 409 的响应体也是 Result，和 200 一样交给调用方按 code 处理
+    if (err.response.status == 409) {
+        return err.response
+    }
     return err
 })
 
@@ -6,25 +6,8 @@ import router from "next/router";
 
 const { TextArea } = Input;
 
-const onFinish = (values: any) => {
-    if (values.birthday) {
-        values.birthday = moment(values.birthday).format("YYYY-MM-DD")
-    }
-    axios.post("/users/edit", values)
-        .then((res) => {
-            if(res.status != 200) {
-                alert(res.statusText);
-                return
-            }
-            if (res.data?.code == 0) {
-                router.push('/users/profile')
-                return
-            }
-            alert(res.data?.msg || "系统错误");
-        }).catch((err) => {
-        alert(err);
-    })
-};
+// 档案在其它地方修改过
+const profileConflict = 40123
 
 const onFinishFailed = (errorInfo: any) => {
     alert("输入有误")
@@ -39,12 +22,44 @@ function EditForm() {
         setLoading(true)
         axios.get('/users/profile')
             .then((res) => res.data)
-            .then((data) => {
-                setData(data)
+            .then((res) => {
+                // 档案在 Result 的 data 字段中
+                setData(res.data)
                 setLoading(false)
             })
     }, [])
 
+    const onFinish = (values: any) => {
+        values.birthday = values.birthday ? moment(values.birthday).format("YYYY-MM-DD") : ""
+        // 只提交修改过的字段
+        const req: any = {version: data.version}
+        for (const key of ["nickname", "birthday", "aboutMe"]) {
+            if ((values[key] ?? "") != ((data as any)[key] ?? "")) {
+                req[key] = values[key] ?? ""
+            }
+        }
+        axios.patch("/users/profile", req)
+            .then((res) => {
+                // 档案被其它地方修改过时状态码是 409
+                if (res.data?.code == profileConflict) {
+                    alert(res.data.msg)
+                    router.reload()
+                    return
+                }
+                if(res.status != 200) {
+                    alert(res.statusText);
+                    return
+                }
+                if (res.data?.code == 0) {
+                    router.push('/users/profile')
+                    return
+                }
+                alert(res.data?.msg || "系统错误");
+            }).catch((err) => {
+            alert(err);
+        })
+    };
+
     if (isLoading) return <p>Loading...</p>
     if (!data) return <p>No profile data</p>
     return <Form
@@ -53,9 +68,10 @@ function EditForm() {
         wrapperCol={{ span: 16 }}
         style={{ maxWidth: 600 }}
         initialValues={{
-            birthday: moment(data.Birthday, 'YYYY-MM-DD'),
-            nickname: data.Nickname,
-            aboutMe: data.AboutMe
+            // 没有填写过生日时不显示默认日期
+            birthday: data.birthday ? moment(data.birthday, 'YYYY-MM-DD') : undefined,
+            nickname: data.nickname,
+            aboutMe: data.aboutMe
         }}
         onFinish={onFinish}
         onFinishFailed={onFinishFailed}
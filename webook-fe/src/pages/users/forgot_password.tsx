@@ -0,0 +1,50 @@
+import React from 'react';
+import { Button, Form, Input } from 'antd';
+import axios from "@/axios/axios";
+import Link from "next/link";
+
+const onFinish = (values: any) => {
+    axios.post("/users/password/reset/send", values)
+        .then((res) => {
+            if(res.status != 200) {
+                alert(res.statusText);
+                return
+            }
+            alert(res.data?.msg || "系统错误");
+        }).catch((err) => {
+            alert(err);
+    })
+};
+
+const onFinishFailed = (errorInfo: any) => {
+    alert("输入有误")
+};
+
+const ForgotPasswordForm: React.FC = () => (
+    <Form
+        name="basic"
+        labelCol={{ span: 8 }}
+        wrapperCol={{ span: 16 }}
+        style={{ maxWidth: 600 }}
+        onFinish={onFinish}
+        onFinishFailed={onFinishFailed}
+        autoComplete="off"
+    >
+        <Form.Item
+            label="邮箱"
+            name="email"
+            rules={[{ required: true, message: '请输入注册时使用的邮箱' }]}
+        >
+            <Input />
+        </Form.Item>
+
+        <Form.Item wrapperCol={{ offset: 8, span: 16 }}>
+            <Button type="primary" htmlType="submit">
+                发送重置邮件
+            </Button>
+            <Link href={"/users/login"}>&nbsp;登录</Link>
+        </Form.Item>
+    </Form>
+);
+
+export default ForgotPasswordForm;
@@ -14,6 +14,11 @@ const onFinish = (values: any) => {
             if(typeof res.data == 'string') {
                 alert(res.data);
             } else {
+                // 开启了两步验证，输入验证码后才能完成登录
+                if(res.data.code == 40117) {
+                    router.push({pathname: '/users/login_mfa', query: {token: res.data.data.mfaToken}})
+                    return
+                }
                 const msg = res.data?.msg || JSON.stringify(res.data)
                 alert(msg);
                 if(res.data.code == 0) {
@@ -70,6 +75,9 @@ const LoginForm: React.FC = () => {
             <Link href={"/users/signup"} >
                 &nbsp;&nbsp;注册
             </Link>
+            <Link href={"/users/forgot_password"} >
+                &nbsp;&nbsp;忘记密码
+            </Link>
         </Form.Item>
     </Form>
 )};
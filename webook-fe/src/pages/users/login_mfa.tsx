@@ -0,0 +1,61 @@
+import React from 'react';
+import { Button, Form, Input } from 'antd';
+import axios from "@/axios/axios";
+import router, { useRouter } from "next/router";
+
+function LoginMFAForm() {
+    // 登录接口返回的 mfaToken
+    const { token } = useRouter().query
+
+    const onFinish = (values: any) => {
+        axios.post("/users/login/mfa", { ...values, mfaToken: token })
+            .then((res) => {
+                if(res.status != 200) {
+                    alert(res.statusText);
+                    return
+                }
+                if (res.data?.code == 0) {
+                    router.push('/users/profile')
+                    return
+                }
+                alert(res.data?.msg || "系统错误");
+                // 登录过期或者错误次数过多，需要重新登录
+                if (res.data?.code == 40119) {
+                    router.push('/users/login')
+                }
+            }).catch((err) => {
+                alert(err);
+        })
+    };
+
+    const onFinishFailed = (errorInfo: any) => {
+        alert("输入有误")
+    };
+
+    return <Form
+        name="basic"
+        labelCol={{ span: 8 }}
+        wrapperCol={{ span: 16 }}
+        style={{ maxWidth: 600 }}
+        onFinish={onFinish}
+        onFinishFailed={onFinishFailed}
+        autoComplete="off"
+    >
+        <Form.Item
+            label="验证码"
+            name="code"
+            extra="验证器应用中的 6 位验证码，或者恢复码"
+            rules={[{ required: true, message: '请输入验证码' }]}
+        >
+            <Input />
+        </Form.Item>
+
+        <Form.Item wrapperCol={{ offset: 8, span: 16 }}>
+            <Button type="primary" htmlType="submit">
+                登录
+            </Button>
+        </Form.Item>
+    </Form>
+}
+
+export default LoginMFAForm;
@@ -15,6 +15,11 @@ const onFinish = (values: any) => {
                 router.push('/users/profile')
                 return;
             }
+            // 开启了两步验证，输入验证码后才能完成登录
+            if (res.data.code == 40117) {
+                router.push({pathname: '/users/login_mfa', query: {token: res.data.data.mfaToken}})
+                return;
+            }
             alert(res.data.msg)
         }).catch((err) => {
         alert(err);
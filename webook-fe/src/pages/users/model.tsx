@@ -1,7 +1,13 @@
 type Profile = {
-    Email: string
-    Phone: string
-    Nickname: string
-    Birthday: string
-    AboutMe:  string
+    email: string
+    phone: string
+    nickname: string
+    // 格式为 YYYY-MM-DD，没有填写时为空
+    birthday: string
+    aboutMe: string
+    avatarUrl: string
+    // 修改档案时带上，档案已经在其它地方修改过时会被拒绝
+    version: number
+    // 最后修改时间，Unix 秒
+    utime: number
 }
\ No newline at end of file
@@ -1,10 +1,10 @@
 import { ProDescriptions } from '@ant-design/pro-components';
 import React, { useState, useEffect } from 'react';
-import { Button } from 'antd';
+import { Button, Upload, message } from 'antd';
 import axios from "@/axios/axios";
 
 function Page() {
-    let p: Profile = {Email: "", Phone: "", Nickname: "", Birthday:"", AboutMe: ""}
+    let p: Profile = {email: "", phone: "", nickname: "", birthday: "", aboutMe: "", avatarUrl: "", version: 0, utime: 0}
     const [data, setData] = useState<Profile>(p)
     const [isLoading, setLoading] = useState(false)
 
@@ -12,12 +12,30 @@ function Page() {
         setLoading(true)
         axios.get('/users/profile')
             .then((res) => res.data)
-            .then((data) => {
-                setData(data)
+            .then((res) => {
+                // 档案在 Result 的 data 字段中
+                setData(res.data)
                 setLoading(false)
             })
     }, [])
 
+    // 上传后服务端裁剪、缩放，返回新的头像地址
+    const uploadAvatar = (options: any) => {
+        const form = new FormData()
+        form.append("avatar", options.file)
+        axios.post('/users/avatar', form)
+            .then((res) => res.data)
+            .then((res) => {
+                if (res.code != 0) {
+                    message.error(res.msg)
+                    options.onError(new Error(res.msg))
+                    return
+                }
+                setData({...data, avatarUrl: res.data.avatarUrl})
+                options.onSuccess(res.data)
+            })
+    }
+
     if (isLoading) return <p>Loading...</p>
     if (!data) return <p>No profile data</p>
 
@@ -26,29 +44,41 @@ function Page() {
             column={1}
             title="个人信息"
         >
+            <ProDescriptions.Item label="头像" valueType="image">
+                {data.avatarUrl}
+            </ProDescriptions.Item>
+            <ProDescriptions.Item>
+                <Upload accept="image/jpeg,image/png,image/gif" showUploadList={false}
+                        customRequest={uploadAvatar}>
+                    <Button>上传头像</Button>
+                </Upload>
+            </ProDescriptions.Item>
             <ProDescriptions.Item label="昵称" valueType="text">
-                {data.Nickname}
+                {data.nickname}
             </ProDescriptions.Item>
             <ProDescriptions.Item
                 // span={1}
                 valueType="text"
                 label="邮箱"
-            >{data.Email}
+            >{data.email}
             </ProDescriptions.Item>
             <ProDescriptions.Item
                 // span={1}
                 valueType="text"
                 label="手机"
-            >{data.Phone}
+            >{data.phone}
             </ProDescriptions.Item>
             <ProDescriptions.Item label="生日" valueType="date">
-                {data.Birthday}
+                {data.birthday}
             </ProDescriptions.Item>
             <ProDescriptions.Item
                 valueType="text"
                 label="关于我"
             >
-                {data.AboutMe}
+                {data.aboutMe}
+            </ProDescriptions.Item>
+            <ProDescriptions.Item label="最后修改" valueType="dateTime">
+                {data.utime ? data.utime * 1000 : undefined}
             </ProDescriptions.Item>
             <ProDescriptions.Item>
                 <Button href={"/users/edit"} type={"primary"}>修改</Button>
@@ -0,0 +1,63 @@
+import React from 'react';
+import { Button, Form, Input } from 'antd';
+import axios from "@/axios/axios";
+import router, { useRouter } from "next/router";
+
+function ResetPasswordForm() {
+    // 重置邮件中的链接带有 token
+    const { token } = useRouter().query
+
+    const onFinish = (values: any) => {
+        axios.post("/users/password/reset", { ...values, token: token })
+            .then((res) => {
+                if(res.status != 200) {
+                    alert(res.statusText);
+                    return
+                }
+                alert(res.data?.msg || "系统错误");
+                if (res.data?.code == 0) {
+                    router.push('/users/login')
+                }
+            }).catch((err) => {
+                alert(err);
+        })
+    };
+
+    const onFinishFailed = (errorInfo: any) => {
+        alert("输入有误")
+    };
+
+    return <Form
+        name="basic"
+        labelCol={{ span: 8 }}
+        wrapperCol={{ span: 16 }}
+        style={{ maxWidth: 600 }}
+        onFinish={onFinish}
+        onFinishFailed={onFinishFailed}
+        autoComplete="off"
+    >
+        <Form.Item
+            label="新密码"
+            name="newPassword"
+            rules={[{ required: true, message: '请输入新密码' }]}
+        >
+            <Input.Password />
+        </Form.Item>
+
+        <Form.Item
+            label="确认密码"
+            name="confirmPassword"
+            rules={[{ required: true, message: '请确认密码' }]}
+        >
+            <Input.Password />
+        </Form.Item>
+
+        <Form.Item wrapperCol={{ offset: 8, span: 16 }}>
+            <Button type="primary" htmlType="submit">
+                重置密码
+            </Button>
+        </Form.Item>
+    </Form>
+}
+
+export default ResetPasswordForm;
@@ -0,0 +1,29 @@
+import React, { useState, useEffect } from 'react';
+import { Button } from 'antd';
+import axios from "@/axios/axios";
+import { useRouter } from "next/router";
+
+function Page() {
+    // 验证邮件中的链接带有 token
+    const router = useRouter()
+    const [msg, setMsg] = useState("正在验证邮箱...")
+
+    useEffect(() => {
+        if (!router.isReady) return
+        axios.post('/users/verify_email', { token: router.query.token })
+            .then((res) => {
+                setMsg(res.data?.msg || "系统错误")
+            }).catch((err) => {
+                setMsg(String(err))
+            })
+    }, [router.isReady, router.query.token])
+
+    return (
+        <div>
+            <p>{msg}</p>
+            <Button href={"/users/login"} type={"primary"}>去登录</Button>
+        </div>
+    )
+}
+
+export default Page